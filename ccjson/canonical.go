@@ -0,0 +1,184 @@
+package ccjson
+
+import (
+  "fmt"
+  "math"
+  "sort"
+  "strconv"
+  "strings"
+  "unicode/utf16"
+  "unicode/utf8"
+)
+
+// Canonicalize returns v as canonical JSON following the JSON
+// Canonicalization Scheme, RFC 8785: no whitespace, object members sorted
+// by key, numbers in their shortest form and strings escaped the same way
+// every time. Numbers too large for a float64 can't be canonicalized.
+// https://www.rfc-editor.org/rfc/rfc8785
+func Canonicalize(v Value) ([]byte, error) {
+  var b strings.Builder
+  if err := canonicalizeValue(&b, v); err != nil {
+    return nil, err
+  }
+  return []byte(b.String()), nil
+}
+
+func canonicalizeValue(b *strings.Builder, v Value) error {
+  // The objects and arrays being written, innermost last. An explicit
+  // stack rather than recursion, as in formatValue.
+  type open struct {
+    value *Value
+    // The members sorted by key
+    members []Member
+    // The next element or member to write
+    next int
+  }
+  var stack []open
+  // write writes a scalar whole, and the opening bracket of an object or
+  // array, pushing it to have its contents written
+  write := func(v *Value) error {
+    switch v.Kind {
+      case KindArray:
+        b.WriteByte('[')
+        stack = append(stack, open{value: v})
+      case KindObject:
+        b.WriteByte('{')
+        stack = append(stack, open{value: v, members: sortedMembers(v.members)})
+      case KindNumber:
+        number, err := canonicalNumber(v.num)
+        if err != nil {
+          return fmt.Errorf("canonicalNumber(): %w", err)
+        }
+        b.WriteString(number)
+      case KindString:
+        if !utf8.ValidString(v.str) {
+          return fmt.Errorf("invalid UTF-8 in string %q", v.str)
+        }
+        b.WriteString(quoteString(v.str, false))
+      default:
+        b.WriteString(formatScalar(*v, &FormatOptions{}))
+    }
+    return nil
+  }
+  if err := write(&v); err != nil {
+    return err
+  }
+  for len(stack) > 0 {
+    top := &stack[len(stack)-1]
+    if top.value.Kind == KindArray && top.next == len(top.value.items) {
+      b.WriteByte(']')
+      stack = stack[:len(stack)-1]
+      continue
+    }
+    if top.value.Kind == KindObject && top.next == len(top.members) {
+      b.WriteByte('}')
+      stack = stack[:len(stack)-1]
+      continue
+    }
+    if top.next > 0 {
+      b.WriteByte(',')
+    }
+    var item *Value
+    if top.value.Kind == KindObject {
+      member := &top.members[top.next]
+      if !utf8.ValidString(member.Key) {
+        return fmt.Errorf("invalid UTF-8 in key %q", member.Key)
+      }
+      b.WriteString(quoteString(member.Key, false))
+      b.WriteByte(':')
+      item = &member.Value
+    } else {
+      item = &top.value.items[top.next]
+    }
+    top.next++
+    // top isn't used after this, write may grow the stack
+    if err := write(item); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// sortedMembers returns a copy of members sorted by key, comparing keys as
+// UTF-16 code units as RFC 8785 requires
+func sortedMembers(members []Member) []Member {
+  sorted := make([]Member, len(members))
+  copy(sorted, members)
+  keys := make([][]uint16, len(members))
+  for idx, member := range sorted {
+    keys[idx] = utf16.Encode([]rune(member.Key))
+  }
+  sort.Sort(byUTF16Key{sorted, keys})
+  return sorted
+}
+
+type byUTF16Key struct {
+  members []Member
+  keys [][]uint16
+}
+
+func (s byUTF16Key) Len() int {
+  return len(s.members)
+}
+func (s byUTF16Key) Swap(i, j int) {
+  s.members[i], s.members[j] = s.members[j], s.members[i]
+  s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+func (s byUTF16Key) Less(i, j int) bool {
+  a, b := s.keys[i], s.keys[j]
+  for idx := 0; idx < len(a) && idx < len(b); idx++ {
+    if a[idx] != b[idx] {
+      return a[idx] < b[idx]
+    }
+  }
+  return len(a) < len(b)
+}
+
+// canonicalNumber formats f the way ECMAScript's Number.prototype.toString
+// does, which is what RFC 8785 specifies: the shortest digits that round
+// trip, written out in full for exponents from -7 to 20 and in e notation
+// otherwise
+func canonicalNumber(f float64) (string, error) {
+  if math.IsInf(f, 0) || math.IsNaN(f) {
+    return "", fmt.Errorf("%v can't be represented in JSON", f)
+  }
+  if f == 0 {
+    // Including -0
+    return "0", nil
+  }
+  sign := ""
+  if f < 0 {
+    sign = "-"
+    f = -f
+  }
+  // d.ddddde±x, split into the digits and the position of the decimal
+  // point relative to them
+  e := strconv.FormatFloat(f, 'e', -1, 64)
+  mantissa, exponent, _ := strings.Cut(e, "e")
+  digits := strings.Replace(mantissa, ".", "", 1)
+  exp, _ := strconv.Atoi(exponent)
+  point := exp+1
+  switch {
+    case len(digits) <= point && point <= 21:
+      return sign + digits + strings.Repeat("0", point-len(digits)), nil
+    case 0 < point && point <= 21:
+      return sign + digits[:point] + "." + digits[point:], nil
+    case -6 < point && point <= 0:
+      return sign + "0." + strings.Repeat("0", -point) + digits, nil
+  }
+  exponentSign := "+"
+  if point-1 < 0 {
+    exponentSign = "-"
+  }
+  if len(digits) > 1 {
+    mantissa = digits[:1] + "." + digits[1:]
+  }
+  return fmt.Sprintf("%s%se%s%d", sign, mantissa, exponentSign, abs(point-1)), nil
+}
+
+func abs(n int) int {
+  if n < 0 {
+    return -n
+  }
+  return n
+}
@@ -0,0 +1,33 @@
+package ccjson
+
+import (
+  "testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+  tests := []struct {
+    doc string
+    want string
+  }{
+    {`{"b": [1.0, 2e1], "a": {"d": null, "c": true}}`, `{"a":{"c":true,"d":null},"b":[1,20]}`},
+    {`[]`, `[]`},
+    {`{}`, `{}`},
+    {`[[], {}, [{}]]`, `[[],{},[{}]]`},
+  }
+  for _, tt := range tests {
+    got, err := Canonicalize(mustParse(t, tt.doc))
+    if err != nil || string(got) != tt.want {
+      t.Errorf("Canonicalize(%s) = %s, %v, want %s", tt.doc, got, err, tt.want)
+    }
+  }
+}
+
+func TestCanonicalizeDeep(t *testing.T) {
+  doc := deepDocument(100000)
+  v := mustParse(t, doc)
+  limitStack(t)
+  got, err := Canonicalize(v)
+  if err != nil || string(got) != doc {
+    t.Errorf("Canonicalize of deep document = %.40s..., %v, want %.40s...", got, err, doc)
+  }
+}
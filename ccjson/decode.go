@@ -0,0 +1,69 @@
+package ccjson
+
+import (
+  "strconv"
+  "strings"
+  "unicode/utf16"
+  "unicode/utf8"
+)
+
+// Decoded values of the single character escapes
+var escapeValues = map[byte]rune{
+  '"': '"',
+  // Single-quoted JSON5 strings only
+  '\'': '\'',
+  '\\': '\\',
+  '/': '/',
+  'b': '\b',
+  'f': '\f',
+  'n': '\n',
+  'r': '\r',
+  't': '\t',
+}
+
+// decodeString returns the contents of string token with its escape
+// sequences replaced by the characters they stand for. token must already
+// have been validated by parseString. A \u escape for half of a surrogate
+// pair that isn't followed by the other half decodes to U+FFFD.
+func decodeString(token string) string {
+  contents := token[1:len(token)-1]
+  if !strings.Contains(contents, "\\") {
+    return contents
+  }
+  var decoded strings.Builder
+  decoded.Grow(len(contents))
+  for idx := 0; idx < len(contents); {
+    if contents[idx] != '\\' {
+      decoded.WriteByte(contents[idx])
+      idx++
+      continue
+    }
+    if contents[idx+1] != 'u' {
+      decoded.WriteRune(escapeValues[contents[idx+1]])
+      idx += 2
+      continue
+    }
+    char := decodeHex4(contents[idx+2:idx+6])
+    idx += 6
+    if utf16.IsSurrogate(char) {
+      low := utf8.RuneError
+      if strings.HasPrefix(contents[idx:], "\\u") {
+        low = decodeHex4(contents[idx+2:idx+6])
+      }
+      if pair := utf16.DecodeRune(char, low); pair != utf8.RuneError {
+        char = pair
+        idx += 6
+      } else {
+        char = utf8.RuneError
+      }
+    }
+    decoded.WriteRune(char)
+  }
+  return decoded.String()
+}
+
+// decodeHex4 decodes the 4 hex digits of a \u escape
+func decodeHex4(hex string) rune {
+  char, _ := strconv.ParseUint(hex, 16, 32)
+  return rune(char)
+}
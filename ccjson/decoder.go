@@ -0,0 +1,86 @@
+package ccjson
+
+import (
+  "bufio"
+  "io"
+)
+
+// Decoder reads a stream of JSON values one after another, such as
+// {"a":1}{"b":2}[3,4], from one input. Unlike ParseLines the values don't
+// need to be on separate lines, whitespace between them is optional
+// wherever tokens can be told apart without it.
+//   dec := NewDecoder(r, DefaultOptions())
+//   for dec.More() {
+//     value, err := dec.Decode()
+//     ...
+//   }
+type Decoder struct {
+  s *scanner
+  opts Options
+  // One token of lookahead, filled by More
+  peeked bool
+  token Token
+  err error
+}
+
+// NewDecoder returns a Decoder reading from r through a buffer, so only
+// the tokens of the value being decoded are held in memory
+func NewDecoder(r io.Reader, opts Options) *Decoder {
+  d := &Decoder{opts: opts}
+  d.s = newScanner(bufio.NewReader(r), &d.opts)
+  return d
+}
+
+func (d *Decoder) peek() (Token, error) {
+  if !d.peeked {
+    d.token, d.err = d.s.next()
+    d.peeked = true
+  }
+  return d.token, d.err
+}
+
+// More reports whether there is another value to decode. It also returns
+// true if reading the input failed, so that Decode returns the error.
+func (d *Decoder) More() bool {
+  _, err := d.peek()
+  return err != io.EOF
+}
+
+// Decode parses the next value in the input, reading only as far as its
+// last token. It returns io.EOF if there are no more values. Positions in
+// errors are in the whole input, TokenIndex counts from the value's first
+// token.
+func (d *Decoder) Decode() (Value, error) {
+  token, err := d.peek()
+  if err != nil {
+    return Value{}, err
+  }
+  d.peeked = false
+  tokens := []Token{token}
+  // Read up to the bracket that closes the first one. Mismatched brackets
+  // are left for parse to report.
+  depth := nesting(token)
+  for depth > 0 {
+    token, err = d.s.next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return Value{}, err
+    }
+    tokens = append(tokens, token)
+    depth += nesting(token)
+  }
+  return parse(tokens, d.s.pos, &d.opts, nil)
+}
+
+// nesting returns how token changes the depth of nesting
+func nesting(token Token) int {
+  switch token.Kind {
+    case TokenStartObject, TokenStartArray:
+      return 1
+    case TokenEndObject, TokenEndArray:
+      return -1
+  }
+  return 0
+}
@@ -0,0 +1,135 @@
+package ccjson
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+)
+
+// ChangeKind is what happened to a value between two documents
+type ChangeKind int
+
+const (
+  // ChangeAdded is a member or element only in the new document
+  ChangeAdded ChangeKind = iota
+  // ChangeRemoved is a member or element only in the old document
+  ChangeRemoved
+  // ChangeModified is a value that is in both documents but differs
+  ChangeModified
+)
+
+func (k ChangeKind) String() string {
+  switch k {
+    case ChangeAdded:
+      return "added"
+    case ChangeRemoved:
+      return "removed"
+    case ChangeModified:
+      return "modified"
+  }
+  return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is one difference found by Diff
+type Change struct {
+  Kind ChangeKind
+  // JSON Pointer to the value, in the old document for removed values and
+  // the new one otherwise
+  Path string
+  // Not set for ChangeAdded
+  Old Value
+  // Not set for ChangeRemoved
+  New Value
+}
+
+// Diff returns the differences between old and new, compared as Equal
+// does. Objects are compared member by member whatever their order, and
+// arrays element by element at the same index, so an element inserted
+// near the start of an array shows as every later element changing.
+// Values of different kinds are reported as modified as a whole.
+func Diff(old, new Value) []Change {
+  // A value still to compare, or with only one of old and new set, to
+  // report as added or removed. An explicit stack rather than recursion,
+  // as in Stats.count, with each level's work pushed last first so
+  // changes come out in document order.
+  type pending struct {
+    path *pathStep
+    old, new *Value
+  }
+  var changes []Change
+  stack := []pending{{nil, &old, &new}}
+  for len(stack) > 0 {
+    top := stack[len(stack)-1]
+    stack = stack[:len(stack)-1]
+    old, new := top.old, top.new
+    switch {
+      case new == nil:
+        changes = append(changes, Change{Kind: ChangeRemoved, Path: top.path.String(), Old: *old})
+      case old == nil:
+        changes = append(changes, Change{Kind: ChangeAdded, Path: top.path.String(), New: *new})
+      case old.Kind == KindObject && new.Kind == KindObject:
+        newValues := make(map[string]*Value, len(new.members))
+        for idx := range new.members {
+          newValues[new.members[idx].Key] = &new.members[idx].Value
+        }
+        oldKeys := make(map[string]bool, len(old.members))
+        var work []pending
+        for idx := range old.members {
+          member := &old.members[idx]
+          oldKeys[member.Key] = true
+          // nil if the member was removed
+          newValue := newValues[member.Key]
+          work = append(work, pending{&pathStep{top.path, member.Key}, &member.Value, newValue})
+        }
+        for idx := range new.members {
+          member := &new.members[idx]
+          if !oldKeys[member.Key] {
+            work = append(work, pending{&pathStep{top.path, member.Key}, nil, &member.Value})
+          }
+        }
+        for idx := len(work) - 1; idx >= 0; idx-- {
+          stack = append(stack, work[idx])
+        }
+      case old.Kind == KindArray && new.Kind == KindArray:
+        count := len(old.items)
+        if len(new.items) > count {
+          count = len(new.items)
+        }
+        for idx := count - 1; idx >= 0; idx-- {
+          next := pending{path: &pathStep{top.path, strconv.Itoa(idx)}}
+          if idx < len(old.items) {
+            next.old = &old.items[idx]
+          }
+          if idx < len(new.items) {
+            next.new = &new.items[idx]
+          }
+          stack = append(stack, next)
+        }
+      case !Equal(*old, *new):
+        // Scalars, or values of different kinds
+        changes = append(changes, Change{Kind: ChangeModified, Path: top.path.String(), Old: *old, New: *new})
+    }
+  }
+  return changes
+}
+
+// pathStep is the last reference token of a JSON Pointer, linked to the
+// ones before it so that Diff only builds the pointer for values that
+// changed
+type pathStep struct {
+  parent *pathStep
+  token string
+}
+
+// String returns the JSON Pointer ending at s, "" for the nil root
+func (s *pathStep) String() string {
+  var tokens []string
+  for step := s; step != nil; step = step.parent {
+    tokens = append(tokens, escapePointerToken(step.token))
+  }
+  var b strings.Builder
+  for idx := len(tokens) - 1; idx >= 0; idx-- {
+    b.WriteString("/" + tokens[idx])
+  }
+  return b.String()
+}
@@ -0,0 +1,167 @@
+package ccjson
+
+import (
+  "fmt"
+)
+
+// Set replaces the value a JSON Pointer refers to within v with value, or
+// adds it if the last step of the pointer is a key v's object doesn't have
+// yet, or "-" for the end of an array. "" replaces v itself. Objects
+// missing part way along the pointer are created if createMissing is set,
+// otherwise they are an error. A value to set can be built, or come from
+// Parse, e.g.
+//   err := v.Set("/servers/0/port", Number(8443), false)
+// The objects and arrays along the pointer are copied rather than changed
+// in place, so copies of v made earlier, and values they share with v,
+// don't see the change.
+func (v *Value) Set(pointer string, value Value, createMissing bool) error {
+  tokens, err := splitPointer(pointer)
+  if err != nil {
+    return err
+  }
+  updated, err := setPath(*v, "", tokens, value, createMissing)
+  if err != nil {
+    return err
+  }
+  *v = updated
+  return nil
+}
+
+// setPath returns current with value set at the path tokens within it.
+// path is the pointer to current, for error messages.
+func setPath(current Value, path string, tokens []string, value Value, createMissing bool) (Value, error) {
+  if len(tokens) == 0 {
+    return value, nil
+  }
+  token := tokens[0]
+  path += "/" + escapePointerToken(token)
+  // Only the last step may add a value, unless createMissing allows
+  // empty objects to be added to continue along the path
+  missing := func(err error) (Value, error) {
+    if len(tokens) > 1 && !createMissing {
+      return Value{}, fmt.Errorf("%s: %w", path, err)
+    }
+    return Value{Kind: KindObject, members: []Member{}}, nil
+  }
+  switch current.Kind {
+    case KindObject:
+      members := make([]Member, len(current.members))
+      copy(members, current.members)
+      idx := current.memberIndex(token)
+      var child Value
+      var err error
+      if idx >= 0 {
+        child = members[idx].Value
+      } else if child, err = missing(fmt.Errorf("no member %q", token)); err != nil {
+        return Value{}, err
+      }
+      child, err = setPath(child, path, tokens[1:], value, createMissing)
+      if err != nil {
+        return Value{}, err
+      }
+      if idx >= 0 {
+        members[idx].Value = child
+      } else {
+        members = append(members, Member{Key: token, Value: child})
+      }
+      current.members = members
+    case KindArray:
+      items := make([]Value, len(current.items))
+      copy(items, current.items)
+      var child Value
+      var err error
+      idx := len(items)
+      if token != "-" {
+        if idx, err = current.itemIndex(token); err != nil {
+          return Value{}, fmt.Errorf("%s: %w", path, err)
+        }
+        child = items[idx]
+      } else if child, err = missing(fmt.Errorf("- is past the end of the array")); err != nil {
+        return Value{}, err
+      }
+      child, err = setPath(child, path, tokens[1:], value, createMissing)
+      if err != nil {
+        return Value{}, err
+      }
+      if idx < len(items) {
+        items[idx] = child
+      } else {
+        items = append(items, child)
+      }
+      current.items = items
+    default:
+      return Value{}, fmt.Errorf("%s: can't look up %q in a %s", path, token, current.Kind)
+  }
+  return current, nil
+}
+
+// Delete removes the object member or array element a JSON Pointer refers
+// to within v. Later elements of an array move down to fill the gap. It is
+// an error if there is nothing at the pointer, or the pointer is "", as v
+// itself can't be removed. Like Set, it copies rather than changes the
+// objects and arrays along the pointer.
+func (v *Value) Delete(pointer string) error {
+  tokens, err := splitPointer(pointer)
+  if err != nil {
+    return err
+  }
+  if len(tokens) == 0 {
+    return fmt.Errorf("can't delete the whole document")
+  }
+  updated, err := deletePath(*v, "", tokens)
+  if err != nil {
+    return err
+  }
+  *v = updated
+  return nil
+}
+
+// deletePath returns current without the value at the path tokens within
+// it. path is the pointer to current, for error messages.
+func deletePath(current Value, path string, tokens []string) (Value, error) {
+  token := tokens[0]
+  path += "/" + escapePointerToken(token)
+  switch current.Kind {
+    case KindObject:
+      idx := current.memberIndex(token)
+      if idx < 0 {
+        return Value{}, fmt.Errorf("%s: no member %q", path, token)
+      }
+      if len(tokens) == 1 {
+        members := make([]Member, 0, len(current.members)-1)
+        members = append(members, current.members[:idx]...)
+        current.members = append(members, current.members[idx+1:]...)
+        return current, nil
+      }
+      child, err := deletePath(current.members[idx].Value, path, tokens[1:])
+      if err != nil {
+        return Value{}, err
+      }
+      members := make([]Member, len(current.members))
+      copy(members, current.members)
+      members[idx].Value = child
+      current.members = members
+    case KindArray:
+      idx, err := current.itemIndex(token)
+      if err != nil {
+        return Value{}, fmt.Errorf("%s: %w", path, err)
+      }
+      if len(tokens) == 1 {
+        items := make([]Value, 0, len(current.items)-1)
+        items = append(items, current.items[:idx]...)
+        current.items = append(items, current.items[idx+1:]...)
+        return current, nil
+      }
+      child, err := deletePath(current.items[idx], path, tokens[1:])
+      if err != nil {
+        return Value{}, err
+      }
+      items := make([]Value, len(current.items))
+      copy(items, current.items)
+      items[idx] = child
+      current.items = items
+    default:
+      return Value{}, fmt.Errorf("%s: can't look up %q in a %s", path, token, current.Kind)
+  }
+  return current, nil
+}
@@ -0,0 +1,64 @@
+package ccjson_test
+
+import (
+  "testing"
+
+  "github.com/tn259/cc-json-parser/ccjson"
+)
+
+// Outside the package, with values built rather than parsed
+func TestSetBuiltValues(t *testing.T) {
+  doc, err := ccjson.Parse([]byte(`{"servers": [{"host": "a", "port": 80}], "name": "x"}`))
+  if err != nil {
+    t.Fatal(err)
+  }
+  original := doc
+  tests := []struct {
+    pointer string
+    value ccjson.Value
+    createMissing bool
+    want string
+  }{
+    {"/servers/0/port", ccjson.Number(8443), false, `{"servers":[{"host":"a","port":8443}],"name":"x"}`},
+    {"/servers/-", ccjson.Object(ccjson.Member{Key: "host", Value: ccjson.String("b")}), false, `{"servers":[{"host":"a","port":80},{"host":"b"}],"name":"x"}`},
+    {"/name", ccjson.Null(), false, `{"servers":[{"host":"a","port":80}],"name":null}`},
+    {"/tags", ccjson.Array(ccjson.String("prod"), ccjson.Bool(true)), false, `{"servers":[{"host":"a","port":80}],"name":"x","tags":["prod",true]}`},
+    {"/tls/cert", ccjson.String("a.pem"), true, `{"servers":[{"host":"a","port":80}],"name":"x","tls":{"cert":"a.pem"}}`},
+    {"", ccjson.Bool(false), false, `false`},
+  }
+  for _, tt := range tests {
+    v := original
+    if err := v.Set(tt.pointer, tt.value, tt.createMissing); err != nil {
+      t.Errorf("Set(%q): %v", tt.pointer, err)
+      continue
+    }
+    if got := ccjson.Compact(v); got != tt.want {
+      t.Errorf("Set(%q) = %s, want %s", tt.pointer, got, tt.want)
+    }
+  }
+  // Each Set copied what it changed rather than changing original
+  if got, want := ccjson.Compact(original), `{"servers":[{"host":"a","port":80}],"name":"x"}`; got != want {
+    t.Errorf("after Set, original = %s, want %s", got, want)
+  }
+}
+
+func TestSetErrors(t *testing.T) {
+  doc, err := ccjson.Parse([]byte(`{"list": [1], "n": 1}`))
+  if err != nil {
+    t.Fatal(err)
+  }
+  pointers := []string{
+    "list",
+    "/tls/cert",
+    "/list/1",
+    "/list/-/x",
+    "/n/x",
+    "/m~2",
+  }
+  for _, pointer := range pointers {
+    v := doc
+    if err := v.Set(pointer, ccjson.Number(2), false); err == nil {
+      t.Errorf("Set(%q) = %s, want an error", pointer, ccjson.Compact(v))
+    }
+  }
+}
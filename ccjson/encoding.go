@@ -0,0 +1,54 @@
+package ccjson
+
+import (
+  "bytes"
+  "fmt"
+  "unicode/utf8"
+)
+
+// Encoding classifies the characters a document contains
+type Encoding int
+
+const (
+  // EncodingASCII - only characters below 0x80
+  EncodingASCII Encoding = iota
+  // EncodingBMP - valid UTF-8, all characters in the Basic Multilingual Plane
+  EncodingBMP
+  // EncodingAstral - valid UTF-8 containing supplementary-plane characters
+  EncodingAstral
+)
+
+func (e Encoding) String() string {
+  switch e {
+    case EncodingASCII:
+      return "ASCII"
+    case EncodingBMP:
+      return "UTF-8 (BMP)"
+    case EncodingAstral:
+      return "UTF-8 (astral)"
+  }
+  return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// DetectEncoding validates data and reports the widest class of
+// characters it contains. Invalid UTF-8 is an ErrInvalidUTF8 error from
+// Parse, with its position. A leading byte order mark isn't part of the
+// document, so doesn't count.
+func DetectEncoding(data []byte) (Encoding, error) {
+  if _, err := Parse(data); err != nil {
+    return EncodingASCII, fmt.Errorf("Parse(): %w", err)
+  }
+  data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+  encoding := EncodingASCII
+  for idx := 0; idx < len(data); {
+    char, size := utf8.DecodeRune(data[idx:])
+    if char > 0xFFFF {
+      return EncodingAstral, nil
+    }
+    if char >= utf8.RuneSelf {
+      encoding = EncodingBMP
+    }
+    idx += size
+  }
+  return encoding, nil
+}
@@ -0,0 +1,68 @@
+package ccjson
+
+import (
+  "errors"
+  "testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+  tests := []struct {
+    name string
+    input string
+    want Encoding
+    wantErr bool
+  }{
+    {"empty object", `{}`, EncodingASCII, false},
+    {"ascii", `{"name": "Zoe", "tags": ["a", "b"]}`, EncodingASCII, false},
+    {"escaped non-ascii stays ascii", `"caf\u00e9 \ud83d\ude00"`, EncodingASCII, false},
+    {"accented latin", `{"name": "Zoë", "city": "Besançon"}`, EncodingBMP, false},
+    {"cjk", `["日本語"]`, EncodingBMP, false},
+    {"emoji", `{"mood": "😀"}`, EncodingAstral, false},
+    {"emoji after accented latin", `["é", "😀"]`, EncodingAstral, false},
+    {"accented latin after emoji", `["😀", "é"]`, EncodingAstral, false},
+    {"byte order mark then ascii", "\ufeff{\"a\": 1}", EncodingASCII, false},
+    {"byte order mark then accented latin", "\ufeff[\"é\"]", EncodingBMP, false},
+    {"invalid utf-8", "[\"a\xffb\"]", EncodingASCII, true},
+    {"truncated utf-8 after accented latin", "[\"é\xc3\"]", EncodingASCII, true},
+    {"valid utf-8, invalid json", `{"é": }`, EncodingASCII, true},
+  }
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T) {
+      got, err := DetectEncoding([]byte(tt.input))
+      if (err != nil) != tt.wantErr {
+        t.Fatalf("DetectEncoding(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+      }
+      if got != tt.want {
+        t.Errorf("DetectEncoding(%q) = %s, want %s", tt.input, got, tt.want)
+      }
+    })
+  }
+}
+
+func TestEncodingString(t *testing.T) {
+  tests := []struct {
+    encoding Encoding
+    want string
+  }{
+    {EncodingASCII, "ASCII"},
+    {EncodingBMP, "UTF-8 (BMP)"},
+    {EncodingAstral, "UTF-8 (astral)"},
+    {Encoding(7), "Encoding(7)"},
+  }
+  for _, tt := range tests {
+    if got := tt.encoding.String(); got != tt.want {
+      t.Errorf("Encoding(%d).String() = %q, want %q", int(tt.encoding), got, tt.want)
+    }
+  }
+}
+
+func TestDetectEncodingInvalidUTF8(t *testing.T) {
+  _, err := DetectEncoding([]byte("[\"ok\",\n \"a\xffb\"]"))
+  if !errors.Is(err, ErrInvalidUTF8) {
+    t.Fatalf("DetectEncoding error = %v, want %v", err, ErrInvalidUTF8)
+  }
+  var parseErr *ParseError
+  if !errors.As(err, &parseErr) || parseErr.Line != 2 || parseErr.Offset != 10 {
+    t.Errorf("DetectEncoding error = %v, want it at line 2, offset 10", err)
+  }
+}
@@ -0,0 +1,108 @@
+package ccjson
+
+import (
+  "math"
+  "math/big"
+  "strings"
+)
+
+// Equal reports whether a and b are the same JSON value, ignoring
+// formatting and the order of object members. Strings are compared by
+// their decoded contents, so "\u0041" equals "A". Numbers are compared as
+// float64 values: 1, 1.0 and 1e0 are equal, and so are numbers too close
+// together to tell apart as float64s. Numbers too large for a float64 are
+// compared exactly instead, so 1e400 equals 10e399 but not 1e500 or
+// Infinity. NaN is not equal to anything.
+func Equal(a, b Value) bool {
+  // Pairs of values still to compare. An explicit stack rather than
+  // recursion, as in Stats.count.
+  type pair struct {
+    a, b *Value
+  }
+  stack := []pair{{&a, &b}}
+  for len(stack) > 0 {
+    top := stack[len(stack)-1]
+    stack = stack[:len(stack)-1]
+    a, b := top.a, top.b
+    if a.Kind != b.Kind {
+      return false
+    }
+    switch a.Kind {
+      case KindBool:
+        if a.boolean != b.boolean {
+          return false
+        }
+      case KindNumber:
+        if !numbersEqual(*a, *b) {
+          return false
+        }
+      case KindString:
+        if a.str != b.str {
+          return false
+        }
+      case KindArray:
+        if len(a.items) != len(b.items) {
+          return false
+        }
+        for idx := range a.items {
+          stack = append(stack, pair{&a.items[idx], &b.items[idx]})
+        }
+      case KindObject:
+        if len(a.members) != len(b.members) {
+          return false
+        }
+        values := make(map[string]*Value, len(a.members))
+        for idx := range a.members {
+          values[a.members[idx].Key] = &a.members[idx].Value
+        }
+        for idx := range b.members {
+          value, ok := values[b.members[idx].Key]
+          if !ok {
+            return false
+          }
+          stack = append(stack, pair{value, &b.members[idx].Value})
+        }
+    }
+  }
+  // Both null, or everything inside them equal
+  return true
+}
+
+func numbersEqual(a, b Value) bool {
+  if !math.IsInf(a.num, 0) && !math.IsInf(b.num, 0) {
+    return a.num == b.num
+  }
+  // Infinity and -Infinity written as such only equal themselves
+  _, aNonFinite := nonFinite[a.numText]
+  _, bNonFinite := nonFinite[b.numText]
+  if aNonFinite || bNonFinite {
+    return a.numText == b.numText
+  }
+  aNeg, aDigits, aExp := decimalParts(a.numText)
+  bNeg, bDigits, bExp := decimalParts(b.numText)
+  return aNeg == bNeg && aDigits == bDigits && aExp.Cmp(bExp) == 0
+}
+
+// decimalParts splits a valid JSON number into its sign, its significant
+// digits without leading or trailing zeros, and the power of ten they are
+// multiplied by, so that numbers with the same value have the same parts.
+// Unlike big.Rat it doesn't compute the power of ten, which for numbers
+// such as 1e1000000000 would take a lot of time and memory.
+func decimalParts(text string) (bool, string, *big.Int) {
+  neg := strings.HasPrefix(text, "-")
+  text = strings.TrimPrefix(text, "-")
+  exp := new(big.Int)
+  if idx := strings.IndexAny(text, "eE"); idx >= 0 {
+    exp.SetString(strings.TrimPrefix(text[idx+1:], "+"), 10)
+    text = text[:idx]
+  }
+  intPart, fraction, _ := strings.Cut(text, ".")
+  exp.Sub(exp, big.NewInt(int64(len(fraction))))
+  digits := strings.TrimLeft(intPart+fraction, "0")
+  trimmed := strings.TrimRight(digits, "0")
+  exp.Add(exp, big.NewInt(int64(len(digits)-len(trimmed))))
+  if trimmed == "" {
+    return false, "", new(big.Int)
+  }
+  return neg, trimmed, exp
+}
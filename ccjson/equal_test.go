@@ -0,0 +1,138 @@
+package ccjson
+
+import (
+  "strings"
+  "testing"
+)
+
+func TestEqual(t *testing.T) {
+  tests := []struct {
+    a string
+    b string
+    want bool
+  }{
+    {`null`, `null`, true},
+    {`true`, `true`, true},
+    {`true`, `false`, false},
+    {`null`, `false`, false},
+    {`"A"`, `"A"`, true},
+    {`"a"`, `"b"`, false},
+    {`1`, `1.0`, true},
+    {`1`, `1e0`, true},
+    {`100`, `1E+2`, true},
+    {`0`, `-0`, true},
+    {`1`, `"1"`, false},
+    {`1`, `2`, false},
+    // Too close together to tell apart as float64s
+    {`9007199254740993`, `9007199254740992`, true},
+    // Out of float64 range, where both become +Inf
+    {`1e400`, `1e400`, true},
+    {`1e400`, `10e399`, true},
+    {`1e400`, `0.1e401`, true},
+    {`1e400`, `1e500`, false},
+    {`1e400`, `-1e400`, false},
+    {`-1e400`, `-1.000e+400`, true},
+    {`1e400`, `1e308`, false},
+    {`1e99999999999999999999`, `1e99999999999999999999`, true},
+    {`1e99999999999999999999`, `1e99999999999999999998`, false},
+    {`[]`, `[]`, true},
+    {`[1, 2]`, `[1, 2]`, true},
+    {`[1, 2]`, `[2, 1]`, false},
+    {`[1, 2]`, `[1, 2, 3]`, false},
+    {`{}`, `{}`, true},
+    {`{"a": 1, "b": [true]}`, `{"b": [true], "a": 1.0}`, true},
+    {`{"a": 1}`, `{"a": 1, "b": 2}`, false},
+    {`{"a": 1}`, `{"b": 1}`, false},
+    {`{"a": {"b": 1e400}}`, `{"a": {"b": 1e500}}`, false},
+    {`{}`, `[]`, false},
+  }
+  for _, tt := range tests {
+    a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+    if got := Equal(a, b); got != tt.want {
+      t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+    }
+    if got := Equal(b, a); got != tt.want {
+      t.Errorf("Equal(%s, %s) = %v, want %v", tt.b, tt.a, got, tt.want)
+    }
+  }
+}
+
+func TestEqualNonFinite(t *testing.T) {
+  opts := DefaultOptions()
+  opts.AllowNonFinite = true
+  parse := func(doc string) Value {
+    v, err := ParseWithOptions([]byte(doc), opts)
+    if err != nil {
+      t.Fatalf("ParseWithOptions(%q): %v", doc, err)
+    }
+    return v
+  }
+  tests := []struct {
+    a string
+    b string
+    want bool
+  }{
+    {`Infinity`, `Infinity`, true},
+    {`-Infinity`, `-Infinity`, true},
+    {`Infinity`, `-Infinity`, false},
+    {`Infinity`, `1e400`, false},
+    {`NaN`, `NaN`, false},
+  }
+  for _, tt := range tests {
+    if got := Equal(parse(tt.a), parse(tt.b)); got != tt.want {
+      t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+    }
+  }
+}
+
+func TestDiffOutOfRangeNumbers(t *testing.T) {
+  changes := Diff(mustParse(t, `{"a": 1e400}`), mustParse(t, `{"a": 1e500}`))
+  if len(changes) != 1 || changes[0].Path != "/a" || changes[0].Kind != ChangeModified {
+    t.Errorf("Diff of 1e400 and 1e500 = %+v, want one change at /a", changes)
+  }
+}
+
+func TestDiff(t *testing.T) {
+  old := mustParse(t, `{"a": [1, 2, 3], "b": {"c": 1, "d~/": 2}, "e": 1, "f": true}`)
+  new := mustParse(t, `{"f": true, "b": {"d~/": 3, "x": null}, "a": [1, 5], "e": "1", "g": []}`)
+  var got []string
+  for _, change := range Diff(old, new) {
+    got = append(got, change.Kind.String()+" "+change.Path)
+  }
+  // In the old document's order, then members only in the new one
+  want := []string{
+    "modified /a/1",
+    "removed /a/2",
+    "removed /b/c",
+    "modified /b/d~0~1",
+    "added /b/x",
+    "modified /e",
+    "added /g",
+  }
+  if strings.Join(got, "\n") != strings.Join(want, "\n") {
+    t.Errorf("Diff = %q, want %q", got, want)
+  }
+  if changes := Diff(old, old); len(changes) != 0 {
+    t.Errorf("Diff of a value with itself = %+v, want none", changes)
+  }
+}
+
+func TestEqualAndDiffDeep(t *testing.T) {
+  a := mustParse(t, deepDocument(100000))
+  b := mustParse(t, deepDocument(100000))
+  different := mustParse(t, strings.Replace(deepDocument(100000), "1", "2", 1))
+  limitStack(t)
+  if !Equal(a, b) {
+    t.Error("Equal of two deep documents = false, want true")
+  }
+  if Equal(a, different) {
+    t.Error("Equal of two different deep documents = true, want false")
+  }
+  if changes := Diff(a, b); len(changes) != 0 {
+    t.Errorf("Diff of two deep documents = %d changes, want none", len(changes))
+  }
+  changes := Diff(a, different)
+  if len(changes) != 1 || changes[0].Kind != ChangeModified || len(changes[0].Path) != len("/a/0")*100000 {
+    t.Errorf("Diff of two different deep documents = %d changes, want one at the innermost value", len(changes))
+  }
+}
@@ -0,0 +1,193 @@
+package ccjson
+
+import (
+  "errors"
+  "fmt"
+  "strings"
+)
+
+// Sentinel errors for errors.Is, wrapped by the errors Parse returns
+var (
+  ErrEmptyInput = errors.New("empty input")
+  // Input that isn't empty but has no value in it
+  ErrNoValue = errors.New("input contains no JSON value, only whitespace")
+  ErrUnexpectedToken = errors.New("unexpected token")
+  // Input that ends part way through a value, e.g. [1,2
+  ErrUnexpectedEOF = errors.New("unexpected end of input")
+  ErrUnterminatedString = errors.New("unterminated string")
+  ErrInvalidEscape = errors.New("invalid escape character")
+  ErrDuplicateKey = errors.New("duplicate key")
+  // Two values in an array or object with nothing between them, e.g.
+  // [1 {}] or {"a":1 "b":2}. Reported at the token where the ',' should
+  // have been.
+  ErrMissingSeparator = errors.New("missing ',' separator")
+  ErrControlCharacter = errors.New("unescaped control character")
+  ErrUnterminatedComment = errors.New("unterminated comment")
+  // Bytes that aren't valid UTF-8, which RFC 8259 requires JSON text to be
+  ErrInvalidUTF8 = errors.New("invalid UTF-8")
+  // Input over Options.MaxInputBytes or Options.MaxTokens
+  ErrLimitExceeded = errors.New("input exceeds limit")
+  // More tokens after a complete top-level value, e.g. {} garbage. Also
+  // matches ErrUnexpectedToken.
+  ErrTrailingData = fmt.Errorf("%w after the top-level value", ErrUnexpectedToken)
+)
+
+// ParseError is returned by Parse when the input is not valid JSON. Line
+// and Col are 1-based, Col counts runes. Offset is the same position as a
+// byte offset. TokenIndex is the index of the token parsing failed at,
+// which is len(tokens) if the input ended early.
+type ParseError struct {
+  Msg string
+  Line int
+  Col int
+  Offset int
+  TokenIndex int
+  // Err is the underlying error, including the trace of parse functions
+  // it was raised through
+  Err error
+}
+
+func (e *ParseError) Error() string {
+  return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Col, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+  return e.Err
+}
+
+// Runes shown either side of the error by FormatError, so a long line,
+// such as a whole minified document, doesn't flood the output
+const snippetContext = 40
+
+// Turns each kind of line break into "\n", so input can be split into
+// lines as the scanner counts them
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// FormatError returns err followed by the line of input it is on, with a
+// ^ under the column, e.g.
+//   line 1, column 7: numbers may not start with '+'
+//   {"a": +1}
+//         ^
+// Long lines are cut down to the part around the column. Blank lines
+// aren't shown.
+func FormatError(input []byte, err ParseError) string {
+  lines := strings.Split(lineBreaks.Replace(string(input)), "\n")
+  if err.Line < 1 || err.Line > len(lines) {
+    return err.Error()
+  }
+  line := []rune(lines[err.Line-1])
+  // A byte order mark isn't counted in columns
+  if err.Line == 1 && len(line) > 0 && line[0] == '\uFEFF' {
+    line = line[1:]
+  }
+  // Nothing worth pointing at, e.g. for empty input
+  if strings.TrimSpace(string(line)) == "" {
+    return err.Error()
+  }
+  col := err.Col-1
+  if col > len(line) {
+    col = len(line)
+  }
+  start, end := 0, len(line)
+  prefix, suffix := "", ""
+  if col > snippetContext {
+    start = col-snippetContext
+    prefix = "..."
+  }
+  if end-col > snippetContext {
+    end = col+snippetContext
+    suffix = "..."
+  }
+  // Tabs are kept in the padding so the caret lines up however wide the
+  // terminal shows them
+  padding := []rune(strings.Repeat(" ", len(prefix)))
+  for _, c := range line[start:col] {
+    if c == '\t' {
+      padding = append(padding, c)
+    } else {
+      padding = append(padding, ' ')
+    }
+  }
+  return fmt.Sprintf("%s\n%s%s%s\n%s^", err.Error(), prefix, string(line[start:end]), suffix, string(padding))
+}
+
+// inTokenError is an error raised partway through a token, so its
+// position can point at the offending rune rather than the token start
+type inTokenError struct {
+  err error
+  // Byte offset into the token
+  offset int
+}
+
+func (e *inTokenError) Error() string {
+  return e.err.Error()
+}
+
+func (e *inTokenError) Unwrap() error {
+  return e.err
+}
+
+// atSpanError is an error positioned where it is raised, for a token the
+// parser may no longer hold by the time the error is found
+type atSpanError struct {
+  err error
+  span Span
+}
+
+func (e *atSpanError) Error() string {
+  return e.err.Error()
+}
+
+func (e *atSpanError) Unwrap() error {
+  return e.err
+}
+
+// advanceSpan moves span, the start of token, forward by offset bytes
+func advanceSpan(span Span, token string, offset int) Span {
+  afterCR := false
+  for _, char := range token[:offset] {
+    span, afterCR = advancePos(span, char, afterCR)
+  }
+  span.Start += offset
+  return span
+}
+
+// advancePos moves the line and column of pos past char. "\r\n", "\n" and
+// a bare "\r" are each one line break, so afterCR says whether the rune
+// before char was '\r'. It returns pos and whether char is '\r'.
+func advancePos(pos Span, char rune, afterCR bool) (Span, bool) {
+  switch {
+    case char == '\n' && afterCR:
+      // The rest of "\r\n", the line was counted at the '\r'
+    case char == '\n' || char == '\r':
+      pos.Line++
+      pos.Col = 1
+    default:
+      pos.Col++
+  }
+  return pos, char == '\r'
+}
+
+// newParseError wraps err, raised at span
+func newParseError(err error, span Span, tokenIdx int) *ParseError {
+  return &ParseError{
+    Msg: innermostMessage(err),
+    Line: span.Line,
+    Col: span.Col,
+    Offset: span.Start,
+    TokenIndex: tokenIdx,
+    Err: err,
+  }
+}
+
+// innermostMessage strips the "parseX(): " trace prefixes added as err was
+// wrapped on the way up, leaving the message of the error first raised
+func innermostMessage(err error) string {
+  for {
+    inner := errors.Unwrap(err)
+    if inner == nil || !strings.HasSuffix(err.Error(), "(): "+inner.Error()) {
+      return err.Error()
+    }
+    err = inner
+  }
+}
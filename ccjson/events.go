@@ -0,0 +1,85 @@
+package ccjson
+
+import (
+  "bufio"
+  "bytes"
+  "io"
+)
+
+// EventHandler is sent the parts of a document by ParseEvents as they are
+// parsed, SAX style, so a large document can be processed without a value
+// tree being built for all of it. Tokens are read only as the parser
+// reaches them, so events for the start of a document are sent before an
+// error further on is found. An error returned by any method stops parsing
+// and is returned by ParseEvents wrapped in a *ParseError.
+type EventHandler interface {
+  StartObject() error
+  EndObject() error
+  StartArray() error
+  EndArray() error
+  // Key is called with the key of each object member, before its value
+  Key(key string) error
+  // Scalar is called for each string, number, boolean and null
+  Scalar(v Value) error
+}
+
+// ParseEvents validates data as Parse does, sending each part of the
+// document to handler instead of building a value tree
+func ParseEvents(data []byte, handler EventHandler) error {
+  return ParseEventsWithOptions(data, DefaultOptions(), handler)
+}
+
+// ParseEventsWithOptions is ParseEvents with behaviour controlled by opts
+func ParseEventsWithOptions(data []byte, opts Options, handler EventHandler) error {
+  return parseEvents(bytes.NewReader(data), &opts, handler)
+}
+
+// ParseEventsReader is ParseEventsWithOptions for input read from r
+// through a buffer. Memory use is bounded by the nesting depth and the
+// longest token rather than the size of the input.
+func ParseEventsReader(r io.Reader, opts Options, handler EventHandler) error {
+  return parseEvents(bufio.NewReader(r), &opts, handler)
+}
+
+func parseEvents(r io.RuneReader, opts *Options, handler EventHandler) error {
+  tokens := &tokenList{s: newScanner(r, opts), stream: true}
+  _, err := parseTokens(tokens, opts, handler)
+  return err
+}
+
+// startEvent reports a container parseElement has opened. An object is
+// opened with its first key already parsed.
+func startEvent(handler EventHandler, c *container) error {
+  if c.value.Kind == KindArray {
+    return handler.StartArray()
+  }
+  if err := handler.StartObject(); err != nil {
+    return err
+  }
+  return handler.Key(c.key)
+}
+
+func endEvent(handler EventHandler, c *container) error {
+  if c.value.Kind == KindArray {
+    return handler.EndArray()
+  }
+  return handler.EndObject()
+}
+
+// valueEvent reports a value parseValue returned whole, a scalar or an
+// empty object or array
+func valueEvent(handler EventHandler, v Value) error {
+  switch v.Kind {
+    case KindArray:
+      if err := handler.StartArray(); err != nil {
+        return err
+      }
+      return handler.EndArray()
+    case KindObject:
+      if err := handler.StartObject(); err != nil {
+        return err
+      }
+      return handler.EndObject()
+  }
+  return handler.Scalar(v)
+}
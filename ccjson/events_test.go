@@ -0,0 +1,202 @@
+package ccjson
+
+import (
+  "bufio"
+  "bytes"
+  "errors"
+  "fmt"
+  "reflect"
+  "testing"
+  "testing/iotest"
+)
+
+// recorder is an EventHandler that records the events it is sent, and
+// returns failWith from the event numbered failAt, counting from 1
+type recorder struct {
+  events []string
+  failAt int
+  failWith error
+}
+
+func (r *recorder) record(event string) error {
+  r.events = append(r.events, event)
+  if len(r.events) == r.failAt {
+    return r.failWith
+  }
+  return nil
+}
+
+func (r *recorder) StartObject() error { return r.record("{") }
+func (r *recorder) EndObject() error { return r.record("}") }
+func (r *recorder) StartArray() error { return r.record("[") }
+func (r *recorder) EndArray() error { return r.record("]") }
+func (r *recorder) Key(key string) error { return r.record("key " + key) }
+func (r *recorder) Scalar(v Value) error { return r.record(fmt.Sprintf("%s %s", v.Kind, Compact(v))) }
+
+func TestParseEvents(t *testing.T) {
+  tests := []struct {
+    doc string
+    want []string
+  }{
+    {`1`, []string{"number 1"}},
+    {`"a"`, []string{`string "a"`}},
+    {`{}`, []string{"{", "}"}},
+    {`[]`, []string{"[", "]"}},
+    {`[[], {}]`, []string{"[", "[", "]", "{", "}", "]"}},
+    {`{"a": {}, "b": []}`, []string{"{", "key a", "{", "}", "key b", "[", "]", "}"}},
+    {
+      `{"name": "x", "list": [1, true, null], "nested": {"k": "v"}}`,
+      []string{
+        "{",
+        "key name", `string "x"`,
+        "key list", "[", "number 1", "bool true", "null null", "]",
+        "key nested", "{", "key k", `string "v"`, "}",
+        "}",
+      },
+    },
+    {`[{"a": [{}]}]`, []string{"[", "{", "key a", "[", "{", "}", "]", "}", "]"}},
+  }
+  for _, tt := range tests {
+    r := &recorder{}
+    if err := ParseEvents([]byte(tt.doc), r); err != nil {
+      t.Errorf("ParseEvents(%s): %v", tt.doc, err)
+      continue
+    }
+    if !reflect.DeepEqual(r.events, tt.want) {
+      t.Errorf("ParseEvents(%s) events = %q, want %q", tt.doc, r.events, tt.want)
+    }
+  }
+}
+
+func TestParseEventsHandlerError(t *testing.T) {
+  errStop := errors.New("stop")
+  doc := `{"a": [1, 2], "b": {"c": 3}, "d": 4}`
+  // Fail at each event in turn, parsing should stop there
+  all := &recorder{}
+  if err := ParseEvents([]byte(doc), all); err != nil {
+    t.Fatalf("ParseEvents(%s): %v", doc, err)
+  }
+  for failAt := 1; failAt <= len(all.events); failAt++ {
+    r := &recorder{failAt: failAt, failWith: errStop}
+    err := ParseEvents([]byte(doc), r)
+    if !errors.Is(err, errStop) {
+      t.Errorf("failing at event %d: error = %v, want %v", failAt, err, errStop)
+    }
+    var parseErr *ParseError
+    if !errors.As(err, &parseErr) {
+      t.Errorf("failing at event %d: error %v is not a *ParseError", failAt, err)
+    }
+    if !reflect.DeepEqual(r.events, all.events[:failAt]) {
+      t.Errorf("failing at event %d: events = %q, want %q", failAt, r.events, all.events[:failAt])
+    }
+  }
+}
+
+func TestParseEventsInvalid(t *testing.T) {
+  r := &recorder{}
+  if err := ParseEvents([]byte(`[1, 2`), r); err == nil {
+    t.Errorf("ParseEvents of an unclosed array succeeded, events %q", r.events)
+  }
+}
+
+func TestParseEventsReader(t *testing.T) {
+  for _, doc := range readerDocuments {
+    want := &recorder{}
+    if err := ParseEvents([]byte(doc), want); err != nil {
+      t.Fatalf("ParseEvents(%q): %v", doc, err)
+    }
+    got := &recorder{}
+    if err := ParseEventsReader(iotest.OneByteReader(bytes.NewReader([]byte(doc))), DefaultOptions(), got); err != nil {
+      t.Errorf("ParseEventsReader(%q): %v", doc, err)
+      continue
+    }
+    if !reflect.DeepEqual(got.events, want.events) {
+      t.Errorf("ParseEventsReader(%q) events = %q, want %q", doc, got.events, want.events)
+    }
+  }
+}
+
+// Reading tokens as they're needed finds the same errors in the same
+// places as tokenizing everything first
+func TestParseEventsErrors(t *testing.T) {
+  docs := []string{
+    ``,
+    " \n ",
+    `[1, 2`,
+    `[1, "abc`,
+    `{"a" 1}`,
+    `[1] 2`,
+    `[1] "abc`,
+    `{"a": 1, "b": [2, 3], "a": 4}`,
+    `{"a": {"b": [1, 2, 3, 4, 5], "b": 2}}`,
+    `"\x"`,
+    `[01]`,
+  }
+  for _, doc := range docs {
+    _, want := Parse([]byte(doc))
+    if want == nil {
+      t.Fatalf("Parse(%q) succeeded, want an error", doc)
+    }
+    err := ParseEvents([]byte(doc), &recorder{})
+    if err == nil || err.Error() != want.Error() {
+      t.Errorf("ParseEvents(%q) error = %v, want %v", doc, err, want)
+    }
+  }
+}
+
+func TestParseEventsWithOptions(t *testing.T) {
+  opts := DefaultOptions()
+  opts.AllowComments = true
+  opts.AllowTrailingCommas = true
+  r := &recorder{}
+  if err := ParseEventsWithOptions([]byte(`[1, /* two */ 2,]`), opts, r); err != nil {
+    t.Fatalf("ParseEventsWithOptions: %v", err)
+  }
+  if want := []string{"[", "number 1", "number 2", "]"}; !reflect.DeepEqual(r.events, want) {
+    t.Errorf("ParseEventsWithOptions events = %q, want %q", r.events, want)
+  }
+  if err := ParseEvents([]byte(`[1,]`), &recorder{}); err == nil {
+    t.Error("ParseEvents of a trailing comma succeeded")
+  }
+}
+
+// counter counts scalars, stopping after limit of them, and records the
+// most tokens held at once
+type counter struct {
+  tokens *tokenList
+  scalars int
+  limit int
+  maxHeld int
+}
+
+func (c *counter) StartObject() error { return nil }
+func (c *counter) EndObject() error { return nil }
+func (c *counter) StartArray() error { return nil }
+func (c *counter) EndArray() error { return nil }
+func (c *counter) Key(key string) error { return nil }
+func (c *counter) Scalar(v Value) error {
+  if held := len(c.tokens.tokens); held > c.maxHeld {
+    c.maxHeld = held
+  }
+  c.scalars++
+  if c.scalars == c.limit {
+    return errStopCounting
+  }
+  return nil
+}
+
+var errStopCounting = errors.New("stop counting")
+
+func TestParseEventsBoundedTokens(t *testing.T) {
+  opts := DefaultOptions()
+  // An array with far more elements than are held
+  data := append([]byte("["), bytes.Repeat([]byte("1,"), 200000)...)
+  tokens := &tokenList{s: newScanner(bufio.NewReader(bytes.NewReader(data)), &opts), stream: true}
+  c := &counter{tokens: tokens, limit: 100000}
+  if _, err := parseTokens(tokens, &opts, c); !errors.Is(err, errStopCounting) {
+    t.Fatalf("parseTokens of a long array error = %v, want %v", err, errStopCounting)
+  }
+  if c.maxHeld > 4 {
+    t.Errorf("parseTokens held %d tokens at once, want a few", c.maxHeld)
+  }
+}
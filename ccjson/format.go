@@ -0,0 +1,236 @@
+package ccjson
+
+import (
+  "fmt"
+  "math"
+  "sort"
+  "strconv"
+  "strings"
+  "unicode/utf16"
+  "unicode/utf8"
+)
+
+// FormatOptions gathers the switches controlling how Format, Compact and
+// Marshal write JSON. The zero value writes it as simply as possible.
+type FormatOptions struct {
+  // EscapeNonASCII writes every character outside ASCII in strings and
+  // keys as a \uXXXX escape, or a surrogate pair of them beyond U+FFFF,
+  // for systems that can't handle UTF-8
+  EscapeNonASCII bool
+  // SortKeys writes the members of every object sorted by key, compared
+  // byte by byte, which is by code point, rather than in document order
+  SortKeys bool
+}
+
+// Format returns v as JSON text with each array element and object member
+// on its own line, indented by indent for each level of nesting. Members
+// keep their order.
+func Format(v Value, indent string) string {
+  return FormatWithOptions(v, indent, FormatOptions{})
+}
+
+// FormatWithOptions is Format with the output controlled by opts
+func FormatWithOptions(v Value, indent string, opts FormatOptions) string {
+  var b strings.Builder
+  formatValue(&b, v, indent, "\n", &opts)
+  return b.String()
+}
+
+// Compact returns v as JSON text with no whitespace, keeping members in
+// order
+func Compact(v Value) string {
+  return CompactWithOptions(v, FormatOptions{})
+}
+
+// CompactWithOptions is Compact with the output controlled by opts
+func CompactWithOptions(v Value, opts FormatOptions) string {
+  var b strings.Builder
+  formatValue(&b, v, "", "", &opts)
+  return b.String()
+}
+
+// Marshal returns v as JSON, written as Compact writes it. Numbers from a
+// parsed document are written exactly as they were in it, so 1e400 or
+// 0.10 come back unchanged rather than as a float64 would print them.
+// Unlike Compact it fails rather than write something that isn't valid
+// JSON: Infinity and NaN, which AllowNonFinite lets through, and strings
+// or keys that aren't valid UTF-8.
+func Marshal(v Value) ([]byte, error) {
+  return MarshalWithOptions(v, FormatOptions{})
+}
+
+// MarshalWithOptions is Marshal with the output controlled by opts
+func MarshalWithOptions(v Value, opts FormatOptions) ([]byte, error) {
+  if err := checkMarshal(v); err != nil {
+    return nil, fmt.Errorf("checkMarshal(): %w", err)
+  }
+  return []byte(CompactWithOptions(v, opts)), nil
+}
+
+// checkMarshal returns an error for the first value in v that can't be
+// written as JSON
+func checkMarshal(v Value) error {
+  // An explicit stack rather than recursion, so nesting is limited by
+  // memory rather than the goroutine stack. Children are pushed last first
+  // to be checked in document order.
+  stack := []*Value{&v}
+  for len(stack) > 0 {
+    top := stack[len(stack)-1]
+    stack = stack[:len(stack)-1]
+    switch top.Kind {
+      case KindArray:
+        for idx := len(top.items) - 1; idx >= 0; idx-- {
+          stack = append(stack, &top.items[idx])
+        }
+      case KindObject:
+        for idx := len(top.members) - 1; idx >= 0; idx-- {
+          stack = append(stack, &top.members[idx].Value)
+        }
+        for _, member := range top.members {
+          if !utf8.ValidString(member.Key) {
+            return fmt.Errorf("invalid UTF-8 in key %q", member.Key)
+          }
+        }
+      case KindNumber:
+        if _, ok := nonFinite[top.numText]; ok || math.IsInf(top.num, 0) && top.numText == "" || math.IsNaN(top.num) {
+          return fmt.Errorf("%s can't be represented in JSON", formatScalar(*top, &FormatOptions{}))
+        }
+      case KindString:
+        if !utf8.ValidString(top.str) {
+          return fmt.Errorf("invalid UTF-8 in string %q", top.str)
+        }
+    }
+  }
+  return nil
+}
+
+// formatValue writes v to b. newline is the line break and indentation
+// for v's own level, each element or member goes one indent further. An
+// empty newline writes v compactly.
+func formatValue(b *strings.Builder, v Value, indent string, newline string, opts *FormatOptions) {
+  // The objects and arrays being written, innermost last. An explicit
+  // stack rather than recursion, as in Stats.count.
+  type open struct {
+    value *Value
+    // The members in the order they're written
+    members []Member
+    // The next element or member to write
+    next int
+    newline string
+  }
+  var stack []open
+  // write writes a scalar or empty container whole, and the opening
+  // bracket of anything else, pushing it to have its contents written
+  write := func(v *Value, newline string) {
+    switch {
+      case v.Kind == KindArray && len(v.items) == 0:
+        b.WriteString("[]")
+      case v.Kind == KindObject && len(v.members) == 0:
+        b.WriteString("{}")
+      case v.Kind == KindArray:
+        b.WriteByte('[')
+        stack = append(stack, open{value: v, newline: newline})
+      case v.Kind == KindObject:
+        members := v.members
+        if opts.SortKeys {
+          members = make([]Member, len(v.members))
+          copy(members, v.members)
+          sort.SliceStable(members, func(i, j int) bool {
+            return members[i].Key < members[j].Key
+          })
+        }
+        b.WriteByte('{')
+        stack = append(stack, open{value: v, members: members, newline: newline})
+      default:
+        b.WriteString(formatScalar(*v, opts))
+    }
+  }
+  write(&v, newline)
+  for len(stack) > 0 {
+    top := &stack[len(stack)-1]
+    if top.value.Kind == KindArray && top.next == len(top.value.items) {
+      b.WriteString(top.newline + "]")
+      stack = stack[:len(stack)-1]
+      continue
+    }
+    if top.value.Kind == KindObject && top.next == len(top.members) {
+      b.WriteString(top.newline + "}")
+      stack = stack[:len(stack)-1]
+      continue
+    }
+    if top.next > 0 {
+      b.WriteByte(',')
+    }
+    inner := top.newline + indent
+    b.WriteString(inner)
+    var item *Value
+    if top.value.Kind == KindObject {
+      member := &top.members[top.next]
+      b.WriteString(quoteString(member.Key, opts.EscapeNonASCII))
+      if top.newline == "" {
+        b.WriteByte(':')
+      } else {
+        b.WriteString(": ")
+      }
+      item = &member.Value
+    } else {
+      item = &top.value.items[top.next]
+    }
+    top.next++
+    // top isn't used after this, write may grow the stack
+    write(item, inner)
+  }
+}
+
+// formatScalar returns the JSON text for a value that isn't an object or
+// array
+func formatScalar(v Value, opts *FormatOptions) string {
+  switch v.Kind {
+    case KindBool:
+      return strconv.FormatBool(v.boolean)
+    case KindNumber:
+      if v.numText != "" {
+        return v.numText
+      }
+      return strconv.FormatFloat(v.num, 'g', -1, 64)
+    case KindString:
+      return quoteString(v.str, opts.EscapeNonASCII)
+  }
+  return "null"
+}
+
+// Escapes used when writing strings, the reverse of escapeValues. '/' is
+// left as it is.
+var quoteEscapes = map[rune]string{
+  '"': "\\\"",
+  '\\': "\\\\",
+  '\b': "\\b",
+  '\f': "\\f",
+  '\n': "\\n",
+  '\r': "\\r",
+  '\t': "\\t",
+}
+
+// quoteString returns s as a JSON string token. Only the characters JSON
+// requires are escaped, other control characters as \u00XX, unless
+// escapeNonASCII is set.
+func quoteString(s string, escapeNonASCII bool) string {
+  var b strings.Builder
+  b.WriteByte('"')
+  for _, c := range s {
+    if escape, ok := quoteEscapes[c]; ok {
+      b.WriteString(escape)
+    } else if c < 0x20 {
+      fmt.Fprintf(&b, "\\u%04x", c)
+    } else if escapeNonASCII && c > 0xFFFF {
+      high, low := utf16.EncodeRune(c)
+      fmt.Fprintf(&b, "\\u%04x\\u%04x", high, low)
+    } else if escapeNonASCII && c >= utf8.RuneSelf {
+      fmt.Fprintf(&b, "\\u%04x", c)
+    } else {
+      b.WriteRune(c)
+    }
+  }
+  b.WriteByte('"')
+  return b.String()
+}
@@ -0,0 +1,150 @@
+package ccjson
+
+import (
+  "math"
+  "runtime/debug"
+  "strings"
+  "testing"
+)
+
+func TestMarshalParsed(t *testing.T) {
+  tests := []struct {
+    doc string
+    want string
+  }{
+    {`null`, `null`},
+    {` { "a" : [ 1 , true , null ] } `, `{"a":[1,true,null]}`},
+    // Numbers are written as they were in the document
+    {`[1.0, 1e400, 0.10, -0, 12345678901234567890]`, `[1.0,1e400,0.10,-0,12345678901234567890]`},
+    {`"é\/\"\\\b\f\n\r\t\u0001"`, "\"é/\\\"\\\\\\b\\f\\n\\r\\t\\u0001\""},
+    {`{"b": 1, "a": {"d": [], "c": {}}}`, `{"b":1,"a":{"d":[],"c":{}}}`},
+  }
+  for _, tt := range tests {
+    got, err := Marshal(mustParse(t, tt.doc))
+    if err != nil {
+      t.Errorf("Marshal(%s): %v", tt.doc, err)
+      continue
+    }
+    if string(got) != tt.want {
+      t.Errorf("Marshal(%s) = %s, want %s", tt.doc, got, tt.want)
+    }
+    // What Marshal writes parses back to the same value
+    if back := mustParse(t, string(got)); !Equal(back, mustParse(t, tt.doc)) {
+      t.Errorf("Marshal(%s) = %s, which parses to a different value", tt.doc, got)
+    }
+  }
+}
+
+func TestMarshalBuilt(t *testing.T) {
+  tests := []struct {
+    name string
+    value Value
+    want string
+  }{
+    {"null", Null(), `null`},
+    {"bool", Bool(false), `false`},
+    {"string", String("a \"b\"\n"), `"a \"b\"\n"`},
+    {"number", Number(1.5), `1.5`},
+    {"whole number", Number(8443), `8443`},
+    {"negative zero", Number(math.Copysign(0, -1)), `0`},
+    {"large number", Number(1e21), `1e+21`},
+    {"small number", Number(1e-7), `1e-7`},
+    {"empty array", Array(), `[]`},
+    {"empty object", Object(), `{}`},
+    {
+      "nested",
+      Object(
+        Member{Key: "name", Value: String("x")},
+        Member{Key: "ports", Value: Array(Number(80), Number(443))},
+        Member{Key: "tls", Value: Object(Member{Key: "enabled", Value: Bool(true)})},
+        Member{Key: "cert", Value: Null()},
+      ),
+      `{"name":"x","ports":[80,443],"tls":{"enabled":true},"cert":null}`,
+    },
+    {"parsed inside built", Array(mustParse(t, `{"a": 1e400}`)), `[{"a":1e400}]`},
+  }
+  for _, tt := range tests {
+    got, err := Marshal(tt.value)
+    if err != nil {
+      t.Errorf("%s: Marshal: %v", tt.name, err)
+      continue
+    }
+    if string(got) != tt.want {
+      t.Errorf("%s: Marshal = %s, want %s", tt.name, got, tt.want)
+    }
+  }
+}
+
+func TestMarshalWithOptions(t *testing.T) {
+  v := Object(
+    Member{Key: "z", Value: String("é\U0001F600")},
+    Member{Key: "a", Value: Array(Object(Member{Key: "y", Value: Null()}, Member{Key: "b", Value: Null()}))},
+  )
+  got, err := MarshalWithOptions(v, FormatOptions{EscapeNonASCII: true, SortKeys: true})
+  if err != nil {
+    t.Fatalf("MarshalWithOptions: %v", err)
+  }
+  want := `{"a":[{"b":null,"y":null}],"z":"\u00e9\ud83d\ude00"}`
+  if string(got) != want {
+    t.Errorf("MarshalWithOptions = %s, want %s", got, want)
+  }
+}
+
+func TestMarshalErrors(t *testing.T) {
+  opts := DefaultOptions()
+  opts.AllowNonFinite = true
+  nonFiniteDoc, err := ParseWithOptions([]byte(`{"a": [1, NaN]}`), opts)
+  if err != nil {
+    t.Fatalf("ParseWithOptions: %v", err)
+  }
+  tests := []struct {
+    name string
+    value Value
+  }{
+    {"infinity", Number(math.Inf(1))},
+    {"negative infinity", Number(math.Inf(-1))},
+    {"NaN", Number(math.NaN())},
+    {"nested NaN", Array(Number(1), Object(Member{Key: "a", Value: Number(math.NaN())}))},
+    {"parsed NaN", nonFiniteDoc},
+    {"invalid UTF-8 string", String("a\xffb")},
+    {"invalid UTF-8 key", Object(Member{Key: "\xc3", Value: Null()})},
+    {"invalid UTF-8 nested", Array(Array(String("\xed\xa0\x80")))},
+  }
+  for _, tt := range tests {
+    if got, err := Marshal(tt.value); err == nil {
+      t.Errorf("%s: Marshal = %s, want an error", tt.name, got)
+    }
+  }
+}
+
+// deepDocument returns pairs objects each holding an array, nested
+// inside each other
+func deepDocument(pairs int) string {
+  return strings.Repeat(`{"a":[`, pairs) + "1" + strings.Repeat("]}", pairs)
+}
+
+// limitStack caps the goroutine stack at 1MB for the rest of the test, far
+// too small for anything walking a deepDocument to recurse
+func limitStack(t *testing.T) {
+  t.Helper()
+  old := debug.SetMaxStack(1 << 20)
+  t.Cleanup(func() {
+    debug.SetMaxStack(old)
+  })
+}
+
+func TestFormatDeep(t *testing.T) {
+  doc := deepDocument(100000)
+  v := mustParse(t, doc)
+  limitStack(t)
+  if got := Compact(v); got != doc {
+    t.Errorf("Compact of deep document = %.40s..., want %.40s...", got, doc)
+  }
+  got, err := Marshal(v)
+  if err != nil || string(got) != doc {
+    t.Errorf("Marshal of deep document = %.40s..., %v, want %.40s...", got, err, doc)
+  }
+  if got := Format(mustParse(t, deepDocument(3)), "  "); got != "{\n  \"a\": [\n    {\n      \"a\": [\n        {\n          \"a\": [\n            1\n          ]\n        }\n      ]\n    }\n  ]\n}" {
+    t.Errorf("Format of nested document = %q", got)
+  }
+}
@@ -0,0 +1,54 @@
+package ccjson
+
+import (
+  "bytes"
+  "encoding/json"
+  "os"
+  "path/filepath"
+  "testing"
+  "unicode/utf8"
+)
+
+// FuzzValid checks Valid agrees with json.Valid, duplicate keys included,
+// apart from the two differences that are deliberate: a leading byte order mark is skipped
+// here, and invalid UTF-8 is rejected here where encoding/json accepts it
+// in strings. Run it with
+//   go test ./ccjson -run '^$' -fuzz FuzzValid
+func FuzzValid(f *testing.F) {
+  seeds := []string{
+    `{"a": [1, -2.5e+3, true, false, null, "é\n"]}`,
+    `[]`,
+    `01`,
+    `1.`,
+    `1.e1`,
+    `-`,
+    `1e`,
+    `"\ud800"`,
+    `"\u00"`,
+    "\"\x1f\"",
+    `[1,]`,
+    `{"a":1}{"b":2}`,
+    `tru`,
+    `{"a":1,"a":2}`,
+  }
+  for _, seed := range seeds {
+    f.Add([]byte(seed))
+  }
+  // The edge cases checked against json.Valid by runtests.sh
+  fixtures, _ := filepath.Glob(filepath.Join("..", "tests", "tests", "stdlib", "*.json"))
+  for _, fixture := range fixtures {
+    data, err := os.ReadFile(fixture)
+    if err != nil {
+      f.Fatal(err)
+    }
+    f.Add(data)
+  }
+  f.Fuzz(func(t *testing.T, data []byte) {
+    if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) || !utf8.Valid(data) {
+      t.Skip()
+    }
+    if got, want := Valid(data), json.Valid(data); got != want {
+      t.Errorf("Valid(%q) = %v, json.Valid = %v", data, got, want)
+    }
+  })
+}
@@ -0,0 +1,41 @@
+package ccjson
+
+import (
+  "fmt"
+)
+
+// Merge returns base with overlay merged into it, for layering documents
+// such as config files. Members only in overlay are added after base's.
+// Members in both are merged recursively if both values are objects,
+// otherwise overlay's value replaces base's in base's position, so arrays
+// are replaced rather than concatenated. Both must be objects. Neither is
+// changed.
+func Merge(base, overlay Value) (Value, error) {
+  if base.Kind != KindObject || overlay.Kind != KindObject {
+    return Value{}, fmt.Errorf("can only merge objects, got %s and %s", base.Kind, overlay.Kind)
+  }
+  return mergeObjects(base, overlay), nil
+}
+
+func mergeObjects(base, overlay Value) Value {
+  members := make([]Member, len(base.members), len(base.members)+len(overlay.members))
+  copy(members, base.members)
+  index := make(map[string]int, len(members))
+  for idx, member := range members {
+    index[member.Key] = idx
+  }
+  for _, member := range overlay.members {
+    idx, ok := index[member.Key]
+    if !ok {
+      index[member.Key] = len(members)
+      members = append(members, member)
+      continue
+    }
+    if members[idx].Value.Kind == KindObject && member.Value.Kind == KindObject {
+      members[idx].Value = mergeObjects(members[idx].Value, member.Value)
+    } else {
+      members[idx].Value = member.Value
+    }
+  }
+  return Value{Kind: KindObject, members: members}
+}
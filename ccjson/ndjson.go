@@ -0,0 +1,44 @@
+package ccjson
+
+import (
+  "bytes"
+  "errors"
+)
+
+// Line is the result of parsing one line of newline-delimited JSON
+type Line struct {
+  // 1-based line number in the input
+  Number int
+  Value Value
+  // A *ParseError, positioned in the whole input rather than the line
+  Err error
+}
+
+// ParseLines parses data as newline-delimited JSON (NDJSON or JSON Lines),
+// where each line is a separate document. Every line is parsed even if an
+// earlier one is invalid. Blank lines are skipped.
+//
+// Lines are split here rather than by the tokenizer, which treats '\n' as
+// whitespace like any other.
+func ParseLines(data []byte, opts Options) []Line {
+  var lines []Line
+  // Byte offset of the start of text
+  offset := 0
+  for idx, text := range bytes.Split(data, []byte("\n")) {
+    lineOffset := offset
+    offset += len(text)+1
+    // Also skips the '\r' of "\r\n" line endings
+    if len(bytes.Trim(text, " \t\r")) == 0 {
+      continue
+    }
+    line := Line{Number: idx+1}
+    line.Value, line.Err = ParseWithOptions(text, opts)
+    var parseErr *ParseError
+    if errors.As(line.Err, &parseErr) {
+      parseErr.Line += idx
+      parseErr.Offset += lineOffset
+    }
+    lines = append(lines, line)
+  }
+  return lines
+}
@@ -0,0 +1,76 @@
+package ccjson
+
+import (
+  "context"
+)
+
+// DuplicateKeyPolicy decides what happens when an object has the same key
+// more than once
+type DuplicateKeyPolicy int
+
+const (
+  // DuplicateKeyError rejects the document with ErrDuplicateKey
+  DuplicateKeyError DuplicateKeyPolicy = iota
+  // DuplicateKeyLastWins keeps the last value, at the position of the
+  // first occurrence of the key
+  DuplicateKeyLastWins
+  // DuplicateKeyFirstWins keeps the first value and ignores the rest
+  DuplicateKeyFirstWins
+)
+
+// Options gathers the switches controlling how a document is parsed. The
+// zero value, also returned by DefaultOptions, is strict RFC 8259. Each
+// field relaxes or tightens one rule.
+type Options struct {
+  DuplicateKeys DuplicateKeyPolicy
+  // RequireContainer rejects a document whose top-level value isn't an
+  // object or array, as the older RFC 4627 did
+  RequireContainer bool
+  // AllowComments skips // line comments and /* */ block comments outside
+  // strings, as in JSONC
+  AllowComments bool
+  // AllowTrailingCommas accepts a single ',' after the last element of an
+  // array or member of an object, e.g. [1,2,]
+  AllowTrailingCommas bool
+  // AllowNonFinite accepts Infinity, -Infinity and NaN as numbers
+  AllowNonFinite bool
+  // JSON5 accepts some of JSON5's extensions: single-quoted strings,
+  // identifiers as object keys, and the comments and trailing commas
+  // above. Other JSON5 syntax, such as hex numbers, is still rejected.
+  // https://spec.json5.org/
+  JSON5 bool
+  // MaxInputBytes fails the parse with ErrLimitExceeded once more than
+  // this many bytes of input have been read. 0 means no limit.
+  MaxInputBytes int
+  // MaxTokens fails the parse with ErrLimitExceeded at the token after
+  // this many. 0 means no limit.
+  MaxTokens int
+  // Set by ParseContext, nil otherwise
+  ctx context.Context
+}
+
+// Tokens read, or values parsed, between checks of ctx. Checking costs a
+// lock in most contexts, so isn't done for every token.
+const contextCheckInterval = 1024
+
+// DefaultOptions returns the options Parse uses: strict RFC 8259
+func DefaultOptions() Options {
+  return Options{}
+}
+
+func (o *Options) allowComments() bool {
+  return o.AllowComments || o.JSON5
+}
+
+func (o *Options) allowTrailingCommas() bool {
+  return o.AllowTrailingCommas || o.JSON5
+}
+
+// checkContext returns ctx's error once it is cancelled or its deadline
+// passes, checking only every contextCheckInterval calls by count
+func (o *Options) checkContext(count int) error {
+  if o.ctx == nil || count%contextCheckInterval != 0 {
+    return nil
+  }
+  return o.ctx.Err()
+}
@@ -0,0 +1,935 @@
+// Package ccjson is a JSON parser following the grammar at
+// https://www.json.org/json-en.html
+//
+// This implementation sets no limits on nesting depths
+// https://www.rfc-editor.org/rfc/rfc8259.html#section-9
+package ccjson
+
+import (
+  "bufio"
+  "context"
+  "errors"
+  "fmt"
+  "io"
+  "math"
+  "strconv"
+  "unicode"
+  "unicode/utf8"
+)
+
+// Parse validates data as a JSON document and returns its value tree
+func Parse(data []byte) (Value, error) {
+  return ParseWithOptions(data, DefaultOptions())
+}
+
+// Valid reports whether data is a valid JSON document, like json.Valid.
+// As there, duplicate keys are allowed, though Parse rejects them. Unlike
+// json.Valid a leading byte order mark is skipped and invalid UTF-8 in
+// strings is rejected.
+func Valid(data []byte) bool {
+  opts := DefaultOptions()
+  opts.DuplicateKeys = DuplicateKeyLastWins
+  _, err := ParseWithOptions(data, opts)
+  return err == nil
+}
+
+// ParseWithOptions is Parse with behaviour controlled by opts
+func ParseWithOptions(data []byte, opts Options) (Value, error) {
+  tokens, end, err := tokenize(data, &opts)
+  if err != nil {
+    return Value{}, err
+  }
+  return parse(tokens, end, &opts, nil)
+}
+
+// ParseContext is Parse, giving up with ctx.Err() if ctx is cancelled or
+// its deadline passes before parsing is done. ctx is checked every so many
+// tokens rather than only at the start, so a huge document can't hold up
+// a cancelled request.
+func ParseContext(ctx context.Context, data []byte) (Value, error) {
+  opts := DefaultOptions()
+  opts.ctx = ctx
+  value, err := ParseWithOptions(data, opts)
+  // Errors from the parser itself come wrapped in a ParseError
+  if ctxErr := ctx.Err(); err != nil && ctxErr != nil && errors.Is(err, ctxErr) {
+    return Value{}, ctxErr
+  }
+  return value, err
+}
+
+// ParseReader is Parse for input read from r. The input is tokenized as
+// it is read through a buffer rather than loaded into memory up front.
+func ParseReader(r io.Reader) (Value, error) {
+  return ParseReaderWithOptions(r, DefaultOptions())
+}
+
+// ParseReaderWithOptions is ParseReader with behaviour controlled by opts.
+// With MaxInputBytes set, reading stops soon after that many bytes, so
+// memory use is bounded however much input r has.
+func ParseReaderWithOptions(r io.Reader, opts Options) (Value, error) {
+  tokens, end, err := newScanner(bufio.NewReader(r), &opts).all(nil)
+  if err != nil {
+    return Value{}, err
+  }
+  return parse(tokens, end, &opts, nil)
+}
+
+// Tokenize splits data into the tokens Parse works on, each with where it
+// is in data. Useful for debugging the tokenizer.
+func Tokenize(data []byte) ([]Token, error) {
+  return TokenizeWithOptions(data, DefaultOptions())
+}
+
+// TokenizeReader calls fn with each token in r as soon as the token has
+// been read, rather than collecting them all first. Memory use is bounded
+// by the longest token rather than the input. It stops at the first error
+// from fn and returns it.
+func TokenizeReader(r io.Reader, opts Options, fn func(token Token) error) error {
+  _, err := newScanner(bufio.NewReader(r), &opts).each(fn)
+  return err
+}
+
+// TokenizeWithOptions is Tokenize with the tokenizer options in opts, such
+// as AllowComments, applied
+func TokenizeWithOptions(data []byte, opts Options) ([]Token, error) {
+  tokens, _, err := tokenize(data, &opts)
+  return tokens, err
+}
+
+// parse parses the tokens of a whole document, end being the position of
+// the end of input
+func parse(tokens []Token, end Span, opts *Options, handler EventHandler) (Value, error) {
+  return parseTokens(&tokenList{tokens: tokens, end: end}, opts, handler)
+}
+
+// json
+//   element
+// handler, if not nil, is sent events for ParseEvents rather than a value
+// tree being built
+func parseTokens(tokens *tokenList, opts *Options, handler EventHandler) (Value, error) {
+  value, idx, err := parseJSON(tokens, opts, handler)
+  // The parser ran out of tokens because reading them failed, that's the
+  // error to report
+  if tokens.err != nil {
+    return Value{}, tokens.err
+  }
+  // The tokenizer drops whitespace, so there was input but no value in it.
+  // parseJSON reports input with nothing at all as ErrEmptyInput.
+  if errors.Is(err, ErrEmptyInput) && tokens.end.Start > 0 {
+    err := ErrNoValue
+    if opts.allowComments() {
+      err = fmt.Errorf("%w or comments", ErrNoValue)
+    }
+    return Value{}, newParseError(err, tokens.end, 0)
+  }
+  if err != nil {
+    span := tokens.end
+    var atSpan *atSpanError
+    if errors.As(err, &atSpan) {
+      span = atSpan.span
+    } else if tokens.inBounds(idx) {
+      span = tokens.at(idx).Span
+      var inToken *inTokenError
+      if errors.As(err, &inToken) {
+        span = advanceSpan(span, tokens.at(idx).Value, inToken.offset)
+      }
+    }
+    return Value{}, newParseError(err, span, idx)
+  }
+  return value, nil
+}
+
+func parseJSON(tokens *tokenList, opts *Options, handler EventHandler) (Value, int, error) {
+  if !tokens.inBounds(0) {
+    return Value{}, 0, ErrEmptyInput
+  }
+  // RFC 8259 allows any value at the top level, not just objects and arrays
+  if first := tokens.at(0); opts.RequireContainer && first.Kind != TokenStartObject && first.Kind != TokenStartArray {
+    return Value{}, 0, fmt.Errorf("expected an object or array at the top level, got %s", first.Value)
+  }
+  idx, value, err := parseElement(0, tokens, opts, handler)
+  if err != nil {
+    return Value{}, idx, fmt.Errorf("parseElement(): %w", err)
+  }
+  if tokens.inBounds(idx) {
+    return Value{}, idx, fmt.Errorf("%w: %s", ErrTrailingData, tokens.at(idx).Value)
+  }
+  return value, idx, nil
+}
+
+// tokenList is the tokens the parser works through, indexed from the start
+// of the document. Either they are all there up front, or they are read
+// from a scanner as the parser reaches them and released once it is past
+// them, so only a few are held at a time.
+type tokenList struct {
+  tokens []Token
+  // The index of tokens[0], more than 0 once tokens have been released
+  first int
+  // Reads more tokens, nil once all of them have been read
+  s *scanner
+  // Tokens are read from s as needed and can be released
+  stream bool
+  // The position of the end of input, once all tokens have been read
+  end Span
+  // Why reading tokens stopped, if not at the end of input
+  err error
+}
+
+// inBounds reports whether there is a token at index, reading up to it
+// from the scanner if need be
+func (l *tokenList) inBounds(index int) bool {
+  for l.s != nil && index >= l.first+len(l.tokens) {
+    token, err := l.s.next()
+    if err != nil {
+      if err != io.EOF {
+        l.err = err
+      }
+      l.end = l.s.pos
+      l.s = nil
+      break
+    }
+    l.tokens = append(l.tokens, token)
+  }
+  return index >= l.first && index < l.first+len(l.tokens)
+}
+
+// at returns the token at index, which must be in bounds
+func (l *tokenList) at(index int) Token {
+  return l.tokens[index-l.first]
+}
+
+func (l *tokenList) get(index int) (Token, error) {
+  if !l.inBounds(index) {
+    return Token{}, ErrUnexpectedEOF
+  }
+  return l.at(index), nil
+}
+
+// release drops the tokens before index if they are being read from a
+// scanner, the parser won't look at them again
+func (l *tokenList) release(index int) {
+  if l.stream && index > l.first {
+    if index > l.first+len(l.tokens) {
+      index = l.first+len(l.tokens)
+    }
+    l.tokens = l.tokens[index-l.first:]
+    l.first = index
+  }
+}
+// Accessing runes within a token. Indexes are byte offsets into the token,
+// as everywhere else in the parser.
+func runeInBounds(index int, token string) bool {
+  return index >= 0 && index < len(token)
+}
+func getRune(index int, token string) (rune, error) {
+  if !runeInBounds(index, token) {
+    return 0, fmt.Errorf("rune index %d out of range in %s", index, token)
+  }
+  c, _ := utf8.DecodeRuneInString(token[index:])
+  return c, nil
+}
+
+// container is an object or array that has been opened but not yet closed
+type container struct {
+  value Value
+  // Objects only: the key of the member being parsed, its token index and
+  // where it is, and the index in value.members of each key already seen,
+  // for detecting duplicate keys
+  key string
+  keyTokenIdx int
+  keySpan Span
+  seen map[string]int
+  // Don't keep the contents, they have been passed to an EventHandler
+  discard bool
+}
+
+// closer is the kind of token that ends c
+func (c *container) closer() TokenKind {
+  if c.value.Kind == KindArray {
+    return TokenEndArray
+  }
+  return TokenEndObject
+}
+
+// add puts a completed element, or the value of the current member, into c
+func (c *container) add(value Value, opts *Options) error {
+  if c.value.Kind == KindArray {
+    if !c.discard {
+      c.value.items = append(c.value.items, value)
+    }
+    return nil
+  }
+  if idx, ok := c.seen[c.key]; ok {
+    switch opts.DuplicateKeys {
+      case DuplicateKeyError:
+        return fmt.Errorf("%w: %q", ErrDuplicateKey, c.key)
+      case DuplicateKeyLastWins:
+        if !c.discard {
+          c.value.members[idx].Value = value
+        }
+      case DuplicateKeyFirstWins:
+        // Keep the value already in members
+    }
+    return nil
+  }
+  c.seen[c.key] = len(c.value.members)
+  if !c.discard {
+    c.value.members = append(c.value.members, Member{Key: c.key, Value: value})
+  }
+  return nil
+}
+
+// element
+//   ws value ws
+//
+// elements
+//   element
+//   element ',' elements
+//
+// members
+//   member
+//   member ',' members
+//
+// The tokenizer drops ws, so none of the parse functions look for it.
+//
+// Objects and arrays are not parsed by recursing into their contents.
+// The ones opened but not yet closed are kept on stack instead, so nesting
+// depth is limited by the heap rather than the goroutine stack. Once a
+// value is complete it is added to the container on top of the stack, and
+// the token after it either starts the next element or member or closes
+// the container, which is then the completed value.
+//
+// With a handler, each part of the document is passed to it as it is
+// parsed instead of being kept, and the value returned is empty.
+func parseElement(currentTokenIdx int, tokens *tokenList, opts *Options, handler EventHandler) (int, Value, error) {
+  var stack []*container
+  for values := 0; ; values++ {
+    if err := opts.checkContext(values); err != nil {
+      return currentTokenIdx, Value{}, err
+    }
+    tokens.release(currentTokenIdx)
+    valueTokenIdx := currentTokenIdx
+    var value Value
+    var opened *container
+    var err error
+    currentTokenIdx, value, opened, err = parseValue(currentTokenIdx, tokens, opts)
+    if err != nil {
+      return currentTokenIdx, Value{}, fmt.Errorf("parseValue(): %w", err)
+    }
+    if opened != nil {
+      stack = append(stack, opened)
+      if handler != nil {
+        opened.discard = true
+        if err := startEvent(handler, opened); err != nil {
+          return valueTokenIdx, Value{}, err
+        }
+      }
+      continue
+    }
+    if handler != nil {
+      if err := valueEvent(handler, value); err != nil {
+        return valueTokenIdx, Value{}, err
+      }
+    }
+    for {
+      if len(stack) == 0 {
+        return currentTokenIdx, value, nil
+      }
+      top := stack[len(stack)-1]
+      if err := top.add(value, opts); err != nil {
+        // The key's token may have been released by now
+        return top.keyTokenIdx, Value{}, &atSpanError{err: err, span: top.keySpan}
+      }
+      token, err := tokens.get(currentTokenIdx)
+      if err != nil {
+        return currentTokenIdx, Value{}, fmt.Errorf("tokens.get(): %w", err)
+      }
+      if token.Kind == TokenComma {
+        currentTokenIdx++
+        if !opts.allowTrailingCommas() || !tokens.inBounds(currentTokenIdx) || tokens.at(currentTokenIdx).Kind != top.closer() {
+          if top.value.Kind == KindObject {
+            currentTokenIdx, err = parseMember(currentTokenIdx, tokens, top, opts)
+            if err != nil {
+              return currentTokenIdx, Value{}, fmt.Errorf("parseMember(): %w", err)
+            }
+            if handler != nil {
+              if err := handler.Key(top.key); err != nil {
+                return top.keyTokenIdx, Value{}, err
+              }
+            }
+          }
+          break
+        }
+        // A trailing comma, e.g. [1,2,], closes the container below as if
+        // it wasn't there
+        token = tokens.at(currentTokenIdx)
+      }
+      if token.Kind != top.closer() {
+        if token.Kind == TokenEndArray || token.Kind == TokenEndObject {
+          return currentTokenIdx, Value{}, fmt.Errorf("expected '%s' but got '%s'", closers[top.closer()], token.Value)
+        }
+        return currentTokenIdx, Value{}, missingSeparator(currentTokenIdx, tokens)
+      }
+      if handler != nil {
+        if err := endEvent(handler, top); err != nil {
+          return currentTokenIdx, Value{}, err
+        }
+      }
+      currentTokenIdx++
+      tokens.release(currentTokenIdx)
+      value = top.value
+      stack = stack[:len(stack)-1]
+    }
+  }
+}
+
+// The text of the tokens that close containers, for error messages
+var closers = map[TokenKind]string{
+  TokenEndArray: "]",
+  TokenEndObject: "}",
+}
+
+// Literals some encoders write for numbers JSON can't represent
+var nonFinite = map[string]float64{
+  "Infinity": math.Inf(1),
+  "-Infinity": math.Inf(-1),
+  "NaN": math.NaN(),
+}
+
+func missingSeparator(currentTokenIdx int, tokens *tokenList) error {
+  return fmt.Errorf("%w before %s", ErrMissingSeparator, tokens.at(currentTokenIdx).Value)
+}
+
+// value
+//   object
+//   array
+//   string
+//   number
+//   "true"
+//   "false"
+//   "null"
+//
+// A non-empty object or array is returned as opened rather than value,
+// for parseElement to fill in
+func parseValue(currentTokenIdx int, tokens *tokenList, opts *Options) (int, Value, *container, error) {
+  token, err := tokens.get(currentTokenIdx)
+  if err != nil {
+    return currentTokenIdx, Value{}, nil, err
+  }
+  // The tokenizer never produces one, but don't index into it if it does
+  if token.Value == "" {
+    return currentTokenIdx, Value{}, nil, fmt.Errorf("%w: empty token", ErrUnexpectedToken)
+  }
+  switch token.Kind {
+    case TokenStartObject:
+      return parseObject(currentTokenIdx+1, tokens, opts)
+    case TokenStartArray:
+      return parseArray(currentTokenIdx+1, tokens)
+    case TokenString:
+      currentTokenIdx, err = parseString(currentTokenIdx, tokens)
+      if err != nil {
+        return currentTokenIdx, Value{}, nil, err
+      }
+      return currentTokenIdx, Value{Kind: KindString, str: decodeString(token.Value)}, nil, nil
+    case TokenLiteral:
+      if token.Value == "null" {
+        return currentTokenIdx+1, Value{Kind: KindNull}, nil, nil
+      }
+      return currentTokenIdx+1, Value{Kind: KindBool, boolean: token.Value == "true"}, nil, nil
+  }
+  if num, ok := nonFinite[token.Value]; ok {
+    if !opts.AllowNonFinite {
+      return currentTokenIdx, Value{}, nil, fmt.Errorf("Infinity/NaN are not valid JSON numbers: %s", token.Value)
+    }
+    return currentTokenIdx+1, Value{Kind: KindNumber, num: num, numText: token.Value}, nil, nil
+  }
+  // Not a number either, such as a misspelt literal, a bare word or
+  // punctuation where a value should be
+  if token.Kind != TokenNumber {
+    return currentTokenIdx, Value{}, nil, fmt.Errorf("%w: %s", ErrUnexpectedToken, token.Value)
+  }
+  if _, err := parseNumber(currentTokenIdx, tokens); err != nil {
+    return currentTokenIdx, Value{}, nil, fmt.Errorf("parseNumber(): %w", err)
+  }
+  // Already validated by parseNumber, so the only possible error is
+  // ErrRange, in which case num is +/-Inf or 0
+  num, _ := strconv.ParseFloat(token.Value, 64)
+  return currentTokenIdx+1, Value{Kind: KindNumber, num: num, numText: token.Value}, nil, nil
+}
+
+// number
+//   integer fraction exponent
+func parseNumber(currentTokenIdx int, tokens *tokenList) (int, error) {
+  numToken, err := tokens.get(currentTokenIdx)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("tokens.get(): %w", err)
+  }
+  token := numToken.Value
+  idx := 0
+  idx, err = parseInteger(idx, token)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("parseInteger(): %w", err)
+  }
+  if idx == len(token) {
+    return currentTokenIdx+1, nil
+  }
+  inBounds := runeInBounds(idx, token)
+  if !inBounds {
+    return currentTokenIdx, fmt.Errorf("rune idx out of bounds")
+  }
+  c, err := getRune(idx, token)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c == '.' {
+    idx, err = parseFraction(idx, token)
+    if err != nil {
+      return currentTokenIdx, fmt.Errorf("parseFraction(): %w", err)
+    }
+  }
+  if idx == len(token) {
+    return currentTokenIdx+1, nil
+  }
+  inBounds = runeInBounds(idx, token)
+  if !inBounds {
+    return currentTokenIdx, fmt.Errorf("rune idx out of bounds")
+  }
+  c, err = getRune(idx, token)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c == 'e' || c == 'E' {
+    idx, err = parseExponent(idx, token)
+    if err != nil {
+      return currentTokenIdx, fmt.Errorf("parseExponent(): %w", err)
+    }
+  }
+  if idx != len(token) {
+    return currentTokenIdx, fmt.Errorf("Unexpected token: %s", token[idx:])
+  }
+  return currentTokenIdx+1, nil
+}
+
+// integer
+//   digit
+//   onenine digits
+//   '-' digit
+//   '-' onenine digits
+func parseInteger(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  // JSON has no unary plus, unlike most languages
+  if c == '+' {
+    return idx, &inTokenError{err: fmt.Errorf("numbers may not start with '+': %s", token), offset: idx}
+  }
+  if c == '-' {
+    idx++
+    if idx == len(token) {
+      return idx, &inTokenError{err: fmt.Errorf("expected digit after '-'"), offset: idx}
+    }
+    c, err = getRune(idx, token)
+    if err != nil {
+      return idx, fmt.Errorf("getRune(): %w", err)
+    }
+    if c < '0' || c > '9' {
+      return idx, &inTokenError{err: fmt.Errorf("expected digit after '-', got %c in %s", c, token), offset: idx}
+    }
+  }
+  // onenine first case
+  if c >= '1' && c <= '9' {
+    idx, err := parseOnenine(idx, token)
+    if err != nil {
+      return idx, fmt.Errorf("parseOnenine(): %w", err)
+    }
+    if idx == len(token) {
+      return idx, nil
+    }
+    idx, err = parseDigits(idx, token)
+    if err != nil {
+      return idx, fmt.Errorf("parseDigits(): %w", err)
+    }
+    return idx, nil
+  }
+  // digit first case
+  idx, err = parseDigit(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("parseDigit(): %w", err)
+  }
+  return idx, nil
+}
+
+// digit
+//   '0'
+//    onenine
+func parseDigit(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c == '0' {
+    return idx+1, nil
+  }
+  idx, err = parseOnenine(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("parseOnenine(): %w", err)
+  }
+  return idx, nil
+}
+
+// digits
+//   digit
+//   digit digits
+func parseDigits(idx int, token string) (int, error) {
+  for {
+    c, err := getRune(idx, token)
+    if err != nil {
+      return idx, fmt.Errorf("getRune(): %w", err)
+    }
+    // Stop at fraction or exponent
+    if c == '.' || c == 'e' || c == 'E' {
+      return idx, nil
+    }
+    idx, err = parseDigit(idx, token)
+    if err != nil {
+      return idx, fmt.Errorf("parseDigit(): %w", err)
+    }
+    if idx == len(token) {
+      return idx, nil
+    }
+  }
+}
+
+// onenine
+//   '1' . '9'
+func parseOnenine(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c < '1' || c > '9' {
+    return idx, fmt.Errorf("Expected onenine, got %c in %s", c, token)
+  }
+  return idx+1, nil
+}
+
+// fraction
+//   "." digits
+func parseFraction(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c != '.' {
+    return idx, fmt.Errorf("Expected '.', got %c in %s", c, token)
+  }
+  idx++
+  // parseDigits would stop at an 'e' straight away, letting 1.e1 through
+  if idx == len(token) || token[idx] < '0' || token[idx] > '9' {
+    return idx, &inTokenError{err: fmt.Errorf("fraction requires at least one digit: %s", token), offset: idx}
+  }
+  idx, err = parseDigits(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("parseDigits(): %w", err)
+  }
+  return idx, nil
+}
+
+// exponent
+//   'E' sign digits
+//   'e' sign digits
+func parseExponent(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c != 'E' && c != 'e' {
+    return idx, fmt.Errorf("Expected 'E' or 'e', got %c in %s", c, token)
+  }
+  idx++
+  if idx < len(token) && (token[idx] == '+' || token[idx] == '-') {
+    idx++
+  }
+  // 1e and 1e+ are common typos, report them at the missing digit
+  if idx == len(token) || token[idx] < '0' || token[idx] > '9' {
+    return idx, &inTokenError{err: fmt.Errorf("exponent requires at least one digit: %s", token), offset: idx}
+  }
+  idx, err = parseDigits(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("parseDigits(): %w", err)
+  }
+  return idx, nil
+}
+
+// sign
+//   '+'
+//   '-'
+func parseSign(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c != '+' && c != '-' {
+    return idx, fmt.Errorf("Expected '+', '-', got %c in %s", c, token)
+  }
+  return idx+1, nil
+}
+
+// member
+//   ws string ws ':' element
+//
+// parseMember parses the member up to its element, setting object's
+// current key. parseElement then parses the element. In JSON5 mode the key
+// can also be an identifier, e.g. {key: 1}.
+func parseMember(currentTokenIdx int, tokens *tokenList, object *container, opts *Options) (int, error) {
+  keyTokenIdx := currentTokenIdx
+  var key string
+  if opts.JSON5 && tokens.inBounds(currentTokenIdx) && isIdentifier(tokens.at(currentTokenIdx).Value) {
+    key = tokens.at(currentTokenIdx).Value
+    currentTokenIdx++
+  } else {
+    var err error
+    currentTokenIdx, err = parseString(currentTokenIdx, tokens)
+    if err != nil {
+      return currentTokenIdx, fmt.Errorf("parseString(): %w", err)
+    }
+    key = decodeString(tokens.at(keyTokenIdx).Value)
+  }
+  token, err := tokens.get(currentTokenIdx)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("tokens.get(): %w", err)
+  }
+  if token.Kind != TokenColon {
+    return currentTokenIdx, fmt.Errorf("Expected ':', got %s", token.Value)
+  }
+  object.key = key
+  object.keyTokenIdx = keyTokenIdx
+  object.keySpan = tokens.at(keyTokenIdx).Span
+  return currentTokenIdx+1, nil
+}
+
+// isIdentifier reports whether token is an ECMAScript style identifier,
+// allowed as an object key in JSON5 mode: a letter, '$' or '_' followed by
+// any of those or digits. Unicode escapes in identifiers aren't supported.
+func isIdentifier(token string) bool {
+  for idx, c := range token {
+    if !unicode.IsLetter(c) && c != '$' && c != '_' && (idx == 0 || !unicode.IsDigit(c)) {
+      return false
+    }
+  }
+  return token != ""
+}
+
+// string
+//   '"' characters '"'
+//
+// In JSON5 mode a string can also be single-quoted, in which case '"'
+// needn't be escaped but '\'' must be
+func parseString(currentTokenIdx int, tokens *tokenList) (int, error) {
+  stringToken, err := tokens.get(currentTokenIdx)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("tokens.get(): %w", err)
+  }
+  token := stringToken.Value
+  // The tokenizer only makes single-quoted strings in JSON5 mode
+  if stringToken.Kind != TokenString {
+    return currentTokenIdx, fmt.Errorf("expected string starting with \", got %s", token)
+  }
+  if len(token) < 2 || token[len(token)-1] != token[0] {
+    return currentTokenIdx, fmt.Errorf("%w: %s", ErrUnterminatedString, token)
+  }
+  idx := 1
+  idx, err = parseCharacters(idx, token)
+  if err != nil {
+    return currentTokenIdx, fmt.Errorf("parseCharacters(): %w", err)
+  }
+  return currentTokenIdx+1, nil
+}
+
+// characters
+//   ""
+//   character characters
+func parseCharacters(idx int, token string) (int, error) {
+  // A loop rather than recursing per character, as strings can be long
+  for idx != len(token)-1 {
+    var err error
+    idx, err = parseCharacter(idx, token)
+    if err != nil {
+      return idx, fmt.Errorf("parseCharacter(): %w", err)
+    }
+  }
+  // The closing quote matches the opening one
+  if token[idx] != token[0] {
+    return idx, fmt.Errorf("%w: %s", ErrUnterminatedString, token)
+  }
+  return idx+1, nil
+}
+
+// character
+//   '0020' . '10FFFF' - '"' - '\'
+//   '\' escape
+func parseCharacter(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c == '\\' {
+    return parseEscape(idx+1, token)
+  }
+  if c < 0x0020 {
+    err := fmt.Errorf("%w %s in string", ErrControlCharacter, describeControl(c))
+    return idx, &inTokenError{err: err, offset: idx}
+  }
+  if c > 0x10FFFF {
+    return idx, fmt.Errorf("expected character, got %q, in %s", c, token)
+  }
+  // Invalid UTF-8 decodes as a one byte RuneError, so step by the encoded
+  // size rather than utf8.RuneLen(c)
+  _, size := utf8.DecodeRuneInString(token[idx:])
+  return idx+size, nil
+}
+
+// Names of the control characters that have a short escape
+var controlNames = map[rune]string{
+  '\b': "backspace, use \\b",
+  '\t': "tab, use \\t",
+  '\n': "newline, use \\n",
+  '\f': "form feed, use \\f",
+  '\r': "carriage return, use \\r",
+}
+
+// describeControl names control character c for error messages, e.g.
+// "U+0009 (tab, use \t)"
+func describeControl(c rune) string {
+  if name, ok := controlNames[c]; ok {
+    return fmt.Sprintf("U+%04X (%s)", c, name)
+  }
+  return fmt.Sprintf("U+%04X (use \\u%04x)", c, c)
+}
+
+// escape
+//   '"'
+//   '\'
+//   '/'
+//   'b'
+//   'f'
+//   'n'
+//   'r'
+//   't'
+//   'u' hex hex hex hex
+func parseEscape(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  switch c {
+    case 'u':
+      idx, err = parseHex(idx+1, token)
+      if err != nil {
+        return idx, fmt.Errorf("parseHex(): %w", err)
+      }
+      idx, err = parseHex(idx, token)
+      if err != nil {
+        return idx, fmt.Errorf("parseHex(): %w", err)
+      }
+      idx, err = parseHex(idx, token)
+      if err != nil {
+        return idx, fmt.Errorf("parseHex(): %w", err)
+      }
+      idx, err = parseHex(idx, token)
+      if err != nil {
+        return idx, fmt.Errorf("parseHex(): %w", err)
+      }
+      return idx, nil
+    case '"':
+      return idx+1, nil
+    case '\'':
+      // Only in a single-quoted JSON5 string
+      if token[0] == '\'' {
+        return idx+1, nil
+      }
+    case '\\':
+      return idx+1, nil
+    case '/':
+      return idx+1, nil
+    case 'b':
+      return idx+1, nil
+    case 'f':
+      return idx+1, nil
+    case 'n':
+      return idx+1, nil
+    case 'r':
+      return idx+1, nil
+    case 't':
+      return idx+1, nil
+  }
+  return idx, fmt.Errorf("%w: %c in %s", ErrInvalidEscape, c, token)
+}
+
+// hex
+//   digit
+//   'A' . 'F'
+//   'a' . 'f'
+func parseHex(idx int, token string) (int, error) {
+  c, err := getRune(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("getRune(): %w", err)
+  }
+  if c >= 'A' && c <= 'F' {
+    return idx+1, nil
+  }
+  if c >= 'a' && c <= 'f' {
+    return idx+1, nil
+  }
+  idx, err = parseDigit(idx, token)
+  if err != nil {
+    return idx, fmt.Errorf("parseDigit(): %w", err)
+  }
+  return idx, nil
+}
+
+// object
+//  '{' ws '}'
+//  '{' members '}'
+//
+// parseObject starts at the token after the '{', which parseValue has
+// already checked. It parses an empty object whole. Otherwise it parses up
+// to the first member's element and returns the opened object.
+func parseObject(currentTokenIdx int, tokens *tokenList, opts *Options) (int, Value, *container, error) {
+  token, err := tokens.get(currentTokenIdx)
+  if err != nil {
+    return currentTokenIdx, Value{}, nil, fmt.Errorf("tokens.get(): %w", err)
+  }
+  // empty object case
+  if token.Kind == TokenEndObject {
+    return currentTokenIdx+1, Value{Kind: KindObject, members: []Member{}}, nil, nil
+  }
+  // members case
+  object := &container{value: Value{Kind: KindObject}, seen: map[string]int{}}
+  currentTokenIdx, err = parseMember(currentTokenIdx, tokens, object, opts)
+  if err != nil {
+    return currentTokenIdx, Value{}, nil, fmt.Errorf("parseMember(): %w", err)
+  }
+  return currentTokenIdx, Value{}, object, nil
+}
+
+// array
+//   '[' ws ']'
+//   '[' elements ']'
+//
+// parseArray starts at the token after the '[', which parseValue has
+// already checked. It parses an empty array whole. Otherwise it returns
+// the opened array, leaving its first element to parseElement.
+func parseArray(currentTokenIdx int, tokens *tokenList) (int, Value, *container, error) {
+  token, err := tokens.get(currentTokenIdx)
+  if err != nil {
+    return currentTokenIdx, Value{}, nil, fmt.Errorf("tokens.get(): %w", err)
+  }
+  // empty array case
+  if token.Kind == TokenEndArray {
+    return currentTokenIdx+1, Value{Kind: KindArray, items: []Value{}}, nil, nil
+  }
+  // elements case
+  return currentTokenIdx, Value{}, &container{value: Value{Kind: KindArray}}, nil
+}
@@ -0,0 +1,309 @@
+package ccjson
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "errors"
+  "io"
+  "reflect"
+  "testing"
+  "testing/iotest"
+  "time"
+)
+
+// Documents whose tokens get split across reads when read a byte at a
+// time: multibyte characters, escapes, numbers and literals
+var readerDocuments = []string{
+  `"héllo wörld"`,
+  `"日本語"`,
+  `{"😀": ["🎉", "e\u0301"]}`,
+  `"é😀\n\t\"\\"`,
+  `-12.5e+10`,
+  `[0, -0, 1234567890, 1.5, 1E-7, 12345678901234567890]`,
+  `[true, false, null]`,
+  `{"a": {"b": [1, {"c": null}]}, "d": "é"}`,
+  "  \r\n[ 1 ,\t2 ]\n",
+}
+
+func TestParseReaderSplitReads(t *testing.T) {
+  readers := map[string]func(io.Reader) io.Reader{
+    "one byte": iotest.OneByteReader,
+    "half": iotest.HalfReader,
+    "data and error": iotest.DataErrReader,
+  }
+  for _, doc := range readerDocuments {
+    want, err := Parse([]byte(doc))
+    if err != nil {
+      t.Fatalf("Parse(%q): %v", doc, err)
+    }
+    for name, reader := range readers {
+      got, err := ParseReader(reader(bytes.NewReader([]byte(doc))))
+      if err != nil {
+        t.Errorf("ParseReader(%s %q): %v", name, doc, err)
+        continue
+      }
+      if !reflect.DeepEqual(got, want) {
+        t.Errorf("ParseReader(%s %q) = %#v, want %#v", name, doc, got, want)
+      }
+    }
+  }
+}
+
+func TestParseReaderSplitReadsInvalid(t *testing.T) {
+  docs := []string{
+    `"héllo`,
+    `[1, 2`,
+    `[tru]`,
+    `01`,
+    "\"\xe6\x97\"",
+    `{"a" 1}`,
+  }
+  for _, doc := range docs {
+    _, wantErr := Parse([]byte(doc))
+    if wantErr == nil {
+      t.Fatalf("Parse(%q) succeeded, want an error", doc)
+    }
+    _, err := ParseReader(iotest.OneByteReader(bytes.NewReader([]byte(doc))))
+    if err == nil || err.Error() != wantErr.Error() {
+      t.Errorf("ParseReader(%q) error = %v, want %v", doc, err, wantErr)
+    }
+  }
+}
+
+// largeArray returns an array of count small objects
+func largeArray(count int) []byte {
+  var b bytes.Buffer
+  b.WriteString("[")
+  for i := 0; i < count; i++ {
+    if i > 0 {
+      b.WriteString(",")
+    }
+    b.WriteString(`{"id": 12345, "name": "item", "tags": ["a", "b"], "ok": true}`)
+  }
+  b.WriteString("]")
+  return b.Bytes()
+}
+
+func TestParseContext(t *testing.T) {
+  want := mustParse(t, `{"a": [1, 2]}`)
+  got, err := ParseContext(context.Background(), []byte(`{"a": [1, 2]}`))
+  if err != nil {
+    t.Fatalf("ParseContext: %v", err)
+  }
+  if !reflect.DeepEqual(got, want) {
+    t.Errorf("ParseContext = %s, want %s", Compact(got), Compact(want))
+  }
+  // Errors in the document come through as from Parse
+  if _, err := ParseContext(context.Background(), []byte(`[1,`)); err == nil {
+    t.Error("ParseContext of invalid JSON succeeded")
+  }
+}
+
+func TestParseContextCancelled(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+  for _, doc := range []string{`1`, `[1, 2]`, string(largeArray(10))} {
+    _, err := ParseContext(ctx, []byte(doc))
+    if err != context.Canceled {
+      t.Errorf("ParseContext(cancelled, %.20s) error = %v, want %v", doc, err, context.Canceled)
+    }
+  }
+}
+
+func TestParseContextDeadline(t *testing.T) {
+  data := largeArray(20000)
+  start := time.Now()
+  if _, err := Parse(data); err != nil {
+    t.Fatal(err)
+  }
+  whole := time.Since(start)
+  // Partway through, a small part of the time the whole parse takes
+  ctx, cancel := context.WithTimeout(context.Background(), whole/20)
+  defer cancel()
+  start = time.Now()
+  _, err := ParseContext(ctx, data)
+  elapsed := time.Since(start)
+  if err != context.DeadlineExceeded {
+    t.Fatalf("ParseContext error = %v, want %v", err, context.DeadlineExceeded)
+  }
+  if elapsed >= whole {
+    t.Errorf("ParseContext took %v to notice the deadline, as long as the whole parse takes", elapsed)
+  }
+}
+
+// endlessReader is an array that never ends: "[" then "1," forever
+type endlessReader struct {
+  started bool
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+  for idx := range p {
+    if !r.started {
+      p[idx] = '['
+      r.started = true
+    } else if idx%2 == 0 {
+      p[idx] = '1'
+    } else {
+      p[idx] = ','
+    }
+  }
+  return len(p), nil
+}
+
+func TestParseReaderWithOptionsMaxInputBytes(t *testing.T) {
+  opts := DefaultOptions()
+  opts.MaxInputBytes = 1 << 16
+  _, err := ParseReaderWithOptions(&endlessReader{}, opts)
+  if !errors.Is(err, ErrLimitExceeded) {
+    t.Fatalf("ParseReaderWithOptions of endless input error = %v, want %v", err, ErrLimitExceeded)
+  }
+  var parseErr *ParseError
+  if !errors.As(err, &parseErr) || parseErr.Offset != opts.MaxInputBytes {
+    t.Errorf("ParseReaderWithOptions error %v, want it at offset %d", err, opts.MaxInputBytes)
+  }
+  // Under the limit it parses as ParseReader does
+  opts.MaxInputBytes = 6
+  if _, err := ParseReaderWithOptions(bytes.NewReader([]byte(`[1, 2]`)), opts); err != nil {
+    t.Errorf("ParseReaderWithOptions of input at the limit: %v", err)
+  }
+}
+
+func TestParseUnexpectedToken(t *testing.T) {
+  docs := []string{`tru`, `[nul]`, `{"a": True}`, `[1, undefined]`, `{"a": x}`, `[1,]`, `{"a":}`, `[}`, `:`, `[,1]`}
+  for _, doc := range docs {
+    _, err := Parse([]byte(doc))
+    if !errors.Is(err, ErrUnexpectedToken) {
+      t.Errorf("Parse(%s) error = %v, want %v", doc, err, ErrUnexpectedToken)
+    }
+  }
+  // Infinity and NaN have their own message
+  _, err := Parse([]byte(`[NaN]`))
+  if err == nil || errors.Is(err, ErrUnexpectedToken) {
+    t.Errorf("Parse([NaN]) error = %v, want the non-finite error", err)
+  }
+}
+
+func TestValid(t *testing.T) {
+  tests := []struct {
+    doc string
+    want bool
+  }{
+    {`{}`, true},
+    {`[]`, true},
+    {`0`, true},
+    {` "a" `, true},
+    {`{"a": [1, -2.5e+3, true, false, null, "é"]}`, true},
+    // A byte order mark is skipped, unlike by json.Valid
+    {"\ufeff[1]", true},
+    // Duplicate keys are allowed, as by json.Valid
+    {`{"a": 1, "a": 2}`, true},
+    {`{"a": {"b": 1, "b": 2}}`, true},
+    {``, false},
+    {` `, false},
+    {`{`, false},
+    {`[1,]`, false},
+    {`{"a" 1}`, false},
+    {`01`, false},
+    {`1 2`, false},
+    {`"\x"`, false},
+    {"\"\x01\"", false},
+    {`[1] x`, false},
+    {`nul`, false},
+    {"[\"\xff\"]", false},
+  }
+  for _, tt := range tests {
+    if got := Valid([]byte(tt.doc)); got != tt.want {
+      t.Errorf("Valid(%q) = %v, want %v", tt.doc, got, tt.want)
+    }
+  }
+}
+
+// Valid never panics, however the input is cut short, and agrees with
+// encoding/json on every truncation
+func TestValidTruncated(t *testing.T) {
+  doc := `{"a": [1, -2.5e+3, {"b": "é\n😀"}, true, false, null], "c": {}, "d": 0.5}`
+  for end := 0; end <= len(doc); end++ {
+    prefix := []byte(doc[:end])
+    if got, want := Valid(prefix), json.Valid(prefix); got != want {
+      t.Errorf("Valid(%q) = %v, json.Valid = %v", prefix, got, want)
+    }
+  }
+}
+
+func TestParseMissingSeparator(t *testing.T) {
+  tests := []struct {
+    doc string
+    // Where the ',' should have been
+    col int
+  }{
+    {`[1 {}]`, 4},
+    {`[1, 2 3]`, 7},
+    {`[[] []]`, 5},
+    {`{"a":1 "b":2}`, 8},
+    {`{"a":{} "b":2}`, 9},
+    {`{"a":[1 2]}`, 9},
+  }
+  for _, tt := range tests {
+    _, err := Parse([]byte(tt.doc))
+    if !errors.Is(err, ErrMissingSeparator) {
+      t.Errorf("Parse(%s) error = %v, want %v", tt.doc, err, ErrMissingSeparator)
+      continue
+    }
+    var parseErr *ParseError
+    if !errors.As(err, &parseErr) || parseErr.Col != tt.col {
+      t.Errorf("Parse(%s) error = %v, want it at column %d", tt.doc, err, tt.col)
+    }
+  }
+}
+
+func TestParseSentinelErrors(t *testing.T) {
+  jsonc := DefaultOptions()
+  jsonc.AllowComments = true
+  tests := []struct {
+    doc string
+    opts Options
+    want error
+  }{
+    {``, DefaultOptions(), ErrEmptyInput},
+    {" \n\t", DefaultOptions(), ErrNoValue},
+    {`"abc`, DefaultOptions(), ErrUnterminatedString},
+    {`["abc]`, DefaultOptions(), ErrUnterminatedString},
+    {`{"a`, DefaultOptions(), ErrUnterminatedString},
+    {`"\x"`, DefaultOptions(), ErrInvalidEscape},
+    {`{"a": "b\q"}`, DefaultOptions(), ErrInvalidEscape},
+    {`{} []`, DefaultOptions(), ErrTrailingData},
+    {`{} []`, DefaultOptions(), ErrUnexpectedToken},
+    {"\"a\tb\"", DefaultOptions(), ErrControlCharacter},
+    {`{"a": 1, "a": 2}`, DefaultOptions(), ErrDuplicateKey},
+    {"[1 /* never closed", jsonc, ErrUnterminatedComment},
+    {"[\"\xff\"]", DefaultOptions(), ErrInvalidUTF8},
+  }
+  for _, tt := range tests {
+    _, err := ParseWithOptions([]byte(tt.doc), tt.opts)
+    if !errors.Is(err, tt.want) {
+      t.Errorf("Parse(%q) error = %v, want %v", tt.doc, err, tt.want)
+    }
+    // With its position, however deep the sentinel is wrapped
+    var parseErr *ParseError
+    if !errors.As(err, &parseErr) {
+      t.Errorf("Parse(%q) error %v is not a *ParseError", tt.doc, err)
+    }
+  }
+}
+
+func TestParseUnexpectedEOF(t *testing.T) {
+  docs := []string{`[`, `[1,2`, `[1,`, `{`, `{"a"`, `{"a":`, `{"a":1,`, `[[[[`}
+  for _, doc := range docs {
+    _, err := Parse([]byte(doc))
+    if !errors.Is(err, ErrUnexpectedEOF) {
+      t.Errorf("Parse(%s) error = %v, want %v", doc, err, ErrUnexpectedEOF)
+      continue
+    }
+    // Reported at the end of the input
+    var parseErr *ParseError
+    if !errors.As(err, &parseErr) || parseErr.Offset != len(doc) {
+      t.Errorf("Parse(%s) error = %v, want it at offset %d", doc, err, len(doc))
+    }
+  }
+}
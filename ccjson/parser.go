@@ -0,0 +1,38 @@
+package ccjson
+
+import (
+  "bytes"
+)
+
+// Parser parses many documents one after another, keeping its token
+// buffer between calls rather than allocating new ones for each
+// document. A Parser isn't safe for concurrent use, give each goroutine
+// its own.
+type Parser struct {
+  opts Options
+  reader bytes.Reader
+  tokens []Token
+}
+
+// NewParser returns a Parser that parses with opts
+func NewParser(opts Options) *Parser {
+  return &Parser{opts: opts}
+}
+
+// Reset changes the options later calls to Parse use, keeping the buffers
+func (p *Parser) Reset(opts Options) {
+  p.opts = opts
+}
+
+// Parse is ParseWithOptions with the Parser's options. The Value returned
+// doesn't share memory with the buffers, so it stays valid after later
+// calls.
+func (p *Parser) Parse(data []byte) (Value, error) {
+  p.reader.Reset(data)
+  tokens, end, err := newScanner(&p.reader, &p.opts).all(p.tokens[:0])
+  if err != nil {
+    return Value{}, err
+  }
+  p.tokens = tokens
+  return parse(tokens, end, &p.opts, nil)
+}
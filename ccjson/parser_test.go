@@ -0,0 +1,63 @@
+package ccjson
+
+import (
+  "reflect"
+  "testing"
+)
+
+// A small document like those a service parses many of
+var smallDocument = []byte(`{"id": 1234, "user": {"name": "Ada", "email": "ada@example.com"}, "tags": ["a", "b", "c"], "active": true, "score": 9.5}`)
+
+func TestParserReuse(t *testing.T) {
+  docs := []string{
+    string(smallDocument),
+    `[1, 2, 3]`,
+    `"a"`,
+    `{"a": [{}, []]}`,
+  }
+  p := NewParser(DefaultOptions())
+  var values []Value
+  for _, doc := range docs {
+    v, err := p.Parse([]byte(doc))
+    if err != nil {
+      t.Fatalf("Parser.Parse(%s): %v", doc, err)
+    }
+    values = append(values, v)
+  }
+  // Values from earlier calls aren't changed by later ones reusing the
+  // buffers
+  for idx, doc := range docs {
+    if want := mustParse(t, doc); !reflect.DeepEqual(values[idx], want) {
+      t.Errorf("Parser.Parse(%s) = %s, want %s", doc, Compact(values[idx]), Compact(want))
+    }
+  }
+  if _, err := p.Parse([]byte(`[1,]`)); err == nil {
+    t.Error("Parser.Parse of a trailing comma succeeded")
+  }
+  opts := DefaultOptions()
+  opts.AllowTrailingCommas = true
+  p.Reset(opts)
+  if _, err := p.Parse([]byte(`[1,]`)); err != nil {
+    t.Errorf("Parser.Parse of a trailing comma after Reset: %v", err)
+  }
+}
+
+func BenchmarkParseWithOptions(b *testing.B) {
+  opts := DefaultOptions()
+  b.ReportAllocs()
+  for i := 0; i < b.N; i++ {
+    if _, err := ParseWithOptions(smallDocument, opts); err != nil {
+      b.Fatal(err)
+    }
+  }
+}
+
+func BenchmarkParser(b *testing.B) {
+  p := NewParser(DefaultOptions())
+  b.ReportAllocs()
+  for i := 0; i < b.N; i++ {
+    if _, err := p.Parse(smallDocument); err != nil {
+      b.Fatal(err)
+    }
+  }
+}
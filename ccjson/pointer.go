@@ -0,0 +1,105 @@
+package ccjson
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+)
+
+// Pointer returns the value a JSON Pointer refers to within v, e.g.
+// "/foo/0/bar", or "" for v itself
+// https://www.rfc-editor.org/rfc/rfc6901
+func (v Value) Pointer(pointer string) (Value, error) {
+  tokens, err := splitPointer(pointer)
+  if err != nil {
+    return Value{}, err
+  }
+  current := v
+  // Path so far, for error messages
+  path := ""
+  for _, token := range tokens {
+    var err error
+    switch current.Kind {
+      case KindObject:
+        current, err = current.member(token)
+      case KindArray:
+        current, err = current.item(token)
+      default:
+        err = fmt.Errorf("can't look up %q in a %s", token, current.Kind)
+    }
+    path += "/" + escapePointerToken(token)
+    if err != nil {
+      return Value{}, fmt.Errorf("%s: %w", path, err)
+    }
+  }
+  return current, nil
+}
+
+// splitPointer returns the reference tokens of a JSON Pointer, unescaped,
+// or none for ""
+func splitPointer(pointer string) ([]string, error) {
+  if pointer == "" {
+    return nil, nil
+  }
+  if pointer[0] != '/' {
+    return nil, fmt.Errorf("JSON pointer %q must be empty or start with /", pointer)
+  }
+  tokens := strings.Split(pointer[1:], "/")
+  for idx, token := range tokens {
+    // ~ is only allowed as the start of ~0 or ~1
+    for i := 0; i < len(token); i++ {
+      if token[i] == '~' && (i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1')) {
+        return nil, fmt.Errorf("JSON pointer %q has an invalid escape, ~ must be followed by 0 or 1", pointer)
+      }
+    }
+    // ~1 first, so that ~01 becomes ~1 rather than /
+    tokens[idx] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+  }
+  return tokens, nil
+}
+
+// member returns the value of an object's member with key
+func (v Value) member(key string) (Value, error) {
+  idx := v.memberIndex(key)
+  if idx < 0 {
+    return Value{}, fmt.Errorf("no member %q", key)
+  }
+  return v.members[idx].Value, nil
+}
+
+// memberIndex returns the index in an object's members of the one with
+// key, or -1 if there isn't one
+func (v Value) memberIndex(key string) int {
+  for idx, member := range v.members {
+    if member.Key == key {
+      return idx
+    }
+  }
+  return -1
+}
+
+// item returns the element of an array at index, given as a pointer token
+func (v Value) item(index string) (Value, error) {
+  idx, err := v.itemIndex(index)
+  if err != nil {
+    return Value{}, err
+  }
+  return v.items[idx], nil
+}
+
+// itemIndex returns index, a pointer token, as an index into an array.
+// RFC 6901 only allows decimal indexes without leading zeros.
+func (v Value) itemIndex(index string) (int, error) {
+  if index == "" || (len(index) > 1 && index[0] == '0') || strings.Trim(index, "0123456789") != "" {
+    return 0, fmt.Errorf("invalid array index %q", index)
+  }
+  idx, err := strconv.Atoi(index)
+  if err != nil || idx >= len(v.items) {
+    return 0, fmt.Errorf("array index %s out of range, length %d", index, len(v.items))
+  }
+  return idx, nil
+}
+
+func escapePointerToken(token string) string {
+  return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,106 @@
+package ccjson
+
+import (
+  "reflect"
+  "testing"
+)
+
+// From RFC 6901 section 5
+const pointerDocument = `{
+  "foo": ["bar", "baz"],
+  "": 0,
+  "a/b": 1,
+  "c%d": 2,
+  "e^f": 3,
+  "g|h": 4,
+  "i\\j": 5,
+  "k\"l": 6,
+  " ": 7,
+  "m~n": 8,
+  "~1": 9,
+  "nested": {"list": [{"x": true}]}
+}`
+
+func TestPointer(t *testing.T) {
+  doc := mustParse(t, pointerDocument)
+  tests := []struct {
+    pointer string
+    want string
+  }{
+    {"", pointerDocument},
+    {"/foo", `["bar", "baz"]`},
+    {"/foo/0", `"bar"`},
+    {"/foo/1", `"baz"`},
+    {"/", "0"},
+    {"/a~1b", "1"},
+    {"/c%d", "2"},
+    {"/e^f", "3"},
+    {"/g|h", "4"},
+    {"/i\\j", "5"},
+    {"/k\"l", "6"},
+    {"/ ", "7"},
+    {"/m~0n", "8"},
+    // ~01 is ~ then 1, not /
+    {"/~01", "9"},
+    {"/nested/list/0/x", "true"},
+  }
+  for _, tt := range tests {
+    got, err := doc.Pointer(tt.pointer)
+    if err != nil {
+      t.Errorf("Pointer(%q): %v", tt.pointer, err)
+      continue
+    }
+    if want := mustParse(t, tt.want); !reflect.DeepEqual(got, want) {
+      t.Errorf("Pointer(%q) = %s, want %s", tt.pointer, Compact(got), tt.want)
+    }
+  }
+}
+
+func TestPointerErrors(t *testing.T) {
+  doc := mustParse(t, pointerDocument)
+  pointers := []string{
+    "foo",
+    "/missing",
+    "/foo/2",
+    "/foo/-",
+    "/foo/01",
+    "/foo/-1",
+    "/foo/x",
+    "/foo/0/x",
+    "/a/b",
+    // Only ~0 and ~1 are escapes
+    "/m~2n",
+    "/m~",
+    "/~",
+    "/nested/~a",
+  }
+  for _, pointer := range pointers {
+    if got, err := doc.Pointer(pointer); err == nil {
+      t.Errorf("Pointer(%q) = %s, want an error", pointer, Compact(got))
+    }
+  }
+}
+
+func TestSplitPointer(t *testing.T) {
+  tests := []struct {
+    pointer string
+    want []string
+  }{
+    {"", nil},
+    {"/", []string{""}},
+    {"//", []string{"", ""}},
+    {"/a~1b/~0", []string{"a/b", "~"}},
+    {"/~01", []string{"~1"}},
+    {"/~10", []string{"/0"}},
+  }
+  for _, tt := range tests {
+    got, err := splitPointer(tt.pointer)
+    if err != nil {
+      t.Errorf("splitPointer(%q): %v", tt.pointer, err)
+      continue
+    }
+    if !reflect.DeepEqual(got, tt.want) {
+      t.Errorf("splitPointer(%q) = %q, want %q", tt.pointer, got, tt.want)
+    }
+  }
+}
@@ -0,0 +1,132 @@
+package ccjson
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+)
+
+// A step in a query path, selecting a member by key, an element by index,
+// or every member or element
+type queryStep struct {
+  key string
+  index int
+  isIndex bool
+  wildcard bool
+}
+
+// Query returns the values matching a JSONPath-style query such as
+// $.users[2].name. Paths start with $ for v and continue with
+//   .key or ['key'] or ["key"]  the member with that key
+//   [n]                         element n of an array, counting from the
+//                               end if negative
+//   .* or [*]                   every member or element
+// Steps that don't match, such as a missing key, give no values rather
+// than an error.
+func (v Value) Query(path string) ([]Value, error) {
+  steps, err := parseQuery(path)
+  if err != nil {
+    return nil, err
+  }
+  matches := []Value{v}
+  for _, step := range steps {
+    var next []Value
+    for _, match := range matches {
+      next = append(next, step.apply(match)...)
+    }
+    matches = next
+  }
+  return matches, nil
+}
+
+// apply returns the values step selects from v
+func (step queryStep) apply(v Value) []Value {
+  switch {
+    case step.wildcard && v.Kind == KindArray:
+      return v.items
+    case step.wildcard && v.Kind == KindObject:
+      values := make([]Value, len(v.members))
+      for idx, member := range v.members {
+        values[idx] = member.Value
+      }
+      return values
+    case step.isIndex && v.Kind == KindArray:
+      idx := step.index
+      if idx < 0 {
+        idx += len(v.items)
+      }
+      if idx >= 0 && idx < len(v.items) {
+        return []Value{v.items[idx]}
+      }
+    case !step.wildcard && !step.isIndex && v.Kind == KindObject:
+      if value, err := v.member(step.key); err == nil {
+        return []Value{value}
+      }
+  }
+  return nil
+}
+
+func parseQuery(path string) ([]queryStep, error) {
+  if !strings.HasPrefix(path, "$") {
+    return nil, fmt.Errorf("query %q must start with $", path)
+  }
+  var steps []queryStep
+  rest := path[1:]
+  for rest != "" {
+    var step queryStep
+    var err error
+    switch rest[0] {
+      case '.':
+        step, rest, err = parseDotStep(rest[1:])
+      case '[':
+        step, rest, err = parseBracketStep(rest[1:])
+      default:
+        err = fmt.Errorf("expected . or [, got %q", rest)
+    }
+    if err != nil {
+      return nil, fmt.Errorf("invalid query %q: %w", path, err)
+    }
+    steps = append(steps, step)
+  }
+  return steps, nil
+}
+
+// parseDotStep parses the key or * after a '.', up to the next '.' or '['
+func parseDotStep(rest string) (queryStep, string, error) {
+  end := strings.IndexAny(rest, ".[")
+  if end < 0 {
+    end = len(rest)
+  }
+  key := rest[:end]
+  if key == "" {
+    return queryStep{}, rest, fmt.Errorf("missing key after .")
+  }
+  if key == "*" {
+    return queryStep{wildcard: true}, rest[end:], nil
+  }
+  return queryStep{key: key}, rest[end:], nil
+}
+
+// parseBracketStep parses a quoted key, index or * after a '[', up to and
+// including the ']'
+func parseBracketStep(rest string) (queryStep, string, error) {
+  if rest != "" && (rest[0] == '\'' || rest[0] == '"') {
+    end := strings.IndexByte(rest[1:], rest[0])
+    if end < 0 || !strings.HasPrefix(rest[end+2:], "]") {
+      return queryStep{}, rest, fmt.Errorf("unterminated key in [%s", rest)
+    }
+    return queryStep{key: rest[1:end+1]}, rest[end+3:], nil
+  }
+  end := strings.IndexByte(rest, ']')
+  if end < 0 {
+    return queryStep{}, rest, fmt.Errorf("missing ] in [%s", rest)
+  }
+  if rest[:end] == "*" {
+    return queryStep{wildcard: true}, rest[end+1:], nil
+  }
+  index, err := strconv.Atoi(rest[:end])
+  if err != nil {
+    return queryStep{}, rest, fmt.Errorf("expected index, quoted key or * in [%s", rest[:end+1])
+  }
+  return queryStep{index: index, isIndex: true}, rest[end+1:], nil
+}
@@ -0,0 +1,80 @@
+package ccjson
+
+import (
+  "fmt"
+)
+
+// Stats summarises a parsed document
+type Stats struct {
+  Objects int
+  Arrays int
+  Strings int
+  Numbers int
+  Booleans int
+  Nulls int
+  // Deepest nesting of objects and arrays, 0 for a top-level scalar
+  MaxDepth int
+  // Tokens in the input, which counts every member of an object even
+  // where duplicate keys were merged into one
+  Tokens int
+}
+
+func (s Stats) String() string {
+  return fmt.Sprintf("%d objects, %d arrays, %d strings, %d numbers, %d booleans, %d nulls, max depth %d, %d tokens",
+    s.Objects, s.Arrays, s.Strings, s.Numbers, s.Booleans, s.Nulls, s.MaxDepth, s.Tokens)
+}
+
+// ParseWithStats is ParseWithOptions that also returns Stats for the
+// document. Object keys aren't counted as strings.
+func ParseWithStats(data []byte, opts Options) (Value, Stats, error) {
+  tokens, end, err := tokenize(data, &opts)
+  if err != nil {
+    return Value{}, Stats{}, err
+  }
+  value, err := parse(tokens, end, &opts, nil)
+  if err != nil {
+    return Value{}, Stats{}, err
+  }
+  stats := Stats{Tokens: len(tokens)}
+  stats.count(value)
+  return value, stats, nil
+}
+
+// count adds v and everything in it to s. Like parseElement it keeps the
+// values still to visit on a stack rather than recursing, so deep nesting
+// doesn't exhaust the goroutine stack.
+func (s *Stats) count(v Value) {
+  type pending struct {
+    value *Value
+    // The number of objects and arrays value is inside
+    depth int
+  }
+  stack := []pending{{&v, 0}}
+  for len(stack) > 0 {
+    top := stack[len(stack)-1]
+    stack = stack[:len(stack)-1]
+    if (top.value.Kind == KindObject || top.value.Kind == KindArray) && top.depth+1 > s.MaxDepth {
+      s.MaxDepth = top.depth+1
+    }
+    switch top.value.Kind {
+      case KindObject:
+        s.Objects++
+        for idx := range top.value.members {
+          stack = append(stack, pending{&top.value.members[idx].Value, top.depth+1})
+        }
+      case KindArray:
+        s.Arrays++
+        for idx := range top.value.items {
+          stack = append(stack, pending{&top.value.items[idx], top.depth+1})
+        }
+      case KindString:
+        s.Strings++
+      case KindNumber:
+        s.Numbers++
+      case KindBool:
+        s.Booleans++
+      case KindNull:
+        s.Nulls++
+    }
+  }
+}
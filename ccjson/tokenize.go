@@ -0,0 +1,369 @@
+package ccjson
+
+import (
+  "bytes"
+  "fmt"
+  "io"
+  "strings"
+  "unicode/utf8"
+)
+
+var singleChars = map[rune]TokenKind{
+  '{': TokenStartObject,
+  '}': TokenEndObject,
+  '[': TokenStartArray,
+  ']': TokenEndArray,
+  ',': TokenComma,
+  ':': TokenColon,
+}
+var wsChars = map[rune]bool{
+  ' ': true,
+  '\t': true,
+  '\n': true,
+  '\r': true,
+}
+var keywords = map[string]bool{
+  "true": true,
+  "false": true,
+  "null": true,
+}
+var escapes = map[rune]bool{
+  '\\': true,
+  '"': true,
+  '/': true,
+  'b': true,
+  'f': true,
+  'n': true,
+  'r': true,
+  't': true,
+  'u': true,
+}
+
+// TokenKind is what a Token is, from its first character
+type TokenKind int
+
+const (
+  TokenStartObject TokenKind = iota
+  TokenEndObject
+  TokenStartArray
+  TokenEndArray
+  TokenComma
+  TokenColon
+  // Quoted with '"', or '\'' in JSON5 mode
+  TokenString
+  // Starting with a digit, '-', '+' or '.', not necessarily a valid number
+  TokenNumber
+  // true, false or null
+  TokenLiteral
+  // Anything else, such as an identifier, NaN or a misspelt literal
+  TokenOther
+)
+
+func (k TokenKind) String() string {
+  switch k {
+    case TokenStartObject:
+      return "start object"
+    case TokenEndObject:
+      return "end object"
+    case TokenStartArray:
+      return "start array"
+    case TokenEndArray:
+      return "end array"
+    case TokenComma:
+      return "comma"
+    case TokenColon:
+      return "colon"
+    case TokenString:
+      return "string"
+    case TokenNumber:
+      return "number"
+    case TokenLiteral:
+      return "literal"
+    case TokenOther:
+      return "other"
+  }
+  return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token is a token of the input, its text exactly as written and where it
+// is. Tokens are checked by the parser, not the tokenizer, so a
+// TokenNumber or TokenString may still be invalid.
+type Token struct {
+  Kind TokenKind
+  Value string
+  Span
+}
+
+// bareKind returns the kind of a token that isn't a string or punctuation
+func bareKind(value string) TokenKind {
+  if keywords[value] {
+    return TokenLiteral
+  }
+  if c := value[0]; c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9') {
+    return TokenNumber
+  }
+  return TokenOther
+}
+
+// Span is where a token is in the input. Start and End are byte offsets,
+// End is exclusive. Line and Col are the 1-based position of Start, Col
+// counts runes.
+type Span struct {
+  Start int
+  End int
+  Line int
+  Col int
+}
+
+// scanner reads tokens one at a time from r, so input can be tokenized
+// as it is read rather than loaded up front. Partial tokens are buffered
+// across reads from r.
+type scanner struct {
+  r io.RuneReader
+  opts *Options
+  // Position of the next rune
+  pos Span
+  // Number of tokens returned so far
+  count int
+  // The last rune read was '\r', so a '\n' now is the same line break
+  afterCR bool
+  // One rune of lookahead, filled by peek
+  peeked bool
+  peekRune rune
+  peekSize int
+  peekErr error
+}
+
+func newScanner(r io.RuneReader, opts *Options) *scanner {
+  s := &scanner{r: r, opts: opts, pos: Span{Line: 1, Col: 1}}
+  // Skip a UTF-8 byte order mark at the very start of input. It isn't
+  // part of the JSON text, so it doesn't count towards the column either.
+  if char, err := s.peek(); err == nil && char == '\uFEFF' {
+    s.peeked = false
+    s.pos.Start += s.peekSize
+  }
+  return s
+}
+
+// peek returns the next rune without reading it. Bytes that aren't valid
+// UTF-8 are an ErrInvalidUTF8 error, its Offset that of the first bad
+// byte, rather than being read as U+FFFD.
+func (s *scanner) peek() (rune, error) {
+  if !s.peeked {
+    s.peekRune, s.peekSize, s.peekErr = s.r.ReadRune()
+    if s.peekErr == nil && s.peekRune == utf8.RuneError && s.peekSize == 1 {
+      s.peekErr = s.errorAt(ErrInvalidUTF8, s.pos)
+    }
+    if max := s.opts.MaxInputBytes; s.peekErr == nil && max > 0 && s.pos.Start+s.peekSize > max {
+      s.peekErr = s.errorAt(fmt.Errorf("%w of %d bytes", ErrLimitExceeded, max), s.pos)
+    }
+    s.peeked = true
+  }
+  return s.peekRune, s.peekErr
+}
+
+func (s *scanner) read() (rune, error) {
+  char, err := s.peek()
+  if err != nil {
+    return 0, err
+  }
+  s.peeked = false
+  s.pos.Start += s.peekSize
+  s.pos, s.afterCR = advancePos(s.pos, char, s.afterCR)
+  return char, nil
+}
+
+// errorAt wraps err as a ParseError at span
+func (s *scanner) errorAt(err error, span Span) error {
+  return newParseError(err, span, s.count)
+}
+
+// next returns the next token, or io.EOF at the end of input
+func (s *scanner) next() (Token, error) {
+  if err := s.opts.checkContext(s.count); err != nil {
+    return Token{}, err
+  }
+  var span Span
+  var char rune
+  for {
+    if err := s.skipWS(); err != nil {
+      return Token{}, err
+    }
+    span = s.pos
+    char, _ = s.read()
+    if char != '/' || !s.opts.allowComments() {
+      break
+    }
+    isComment, err := s.skipComment(span)
+    if err != nil {
+      return Token{}, err
+    }
+    // A lone '/' is left to fail in the parser as it would without
+    // comments allowed
+    if !isComment {
+      break
+    }
+  }
+  if max := s.opts.MaxTokens; max > 0 && s.count >= max {
+    return Token{}, s.errorAt(fmt.Errorf("%w of %d tokens", ErrLimitExceeded, max), span)
+  }
+  var token strings.Builder
+  token.WriteRune(char)
+  kind, isSingle := singleChars[char]
+  var err error
+  if char == '"' || (char == '\'' && s.opts.JSON5) {
+    kind = TokenString
+    err = s.scanString(&token, span, char)
+  } else if !isSingle {
+    err = s.scanBare(&token)
+    kind = bareKind(token.String())
+  }
+  if err != nil {
+    return Token{}, err
+  }
+  span.End = s.pos.Start
+  s.count++
+  return Token{Kind: kind, Value: token.String(), Span: span}, nil
+}
+
+func (s *scanner) skipWS() error {
+  for {
+    char, err := s.peek()
+    if err != nil {
+      return err
+    }
+    if _, ok := wsChars[char]; !ok {
+      return nil
+    }
+    s.read()
+  }
+}
+
+// skipComment skips the rest of a // or /* */ comment, given the '/' at
+// span has just been read. It returns false, having read nothing more, if
+// the '/' doesn't start a comment.
+func (s *scanner) skipComment(span Span) (bool, error) {
+  char, err := s.peek()
+  if err == io.EOF || (err == nil && char != '/' && char != '*') {
+    return false, nil
+  }
+  if err != nil {
+    return false, err
+  }
+  s.read()
+  if char == '/' {
+    // A line comment ends at the line break, "\n", "\r" or "\r\n", or the
+    // end of input
+    for {
+      char, err := s.read()
+      if err == io.EOF || char == '\n' || char == '\r' {
+        return true, nil
+      }
+      if err != nil {
+        return false, err
+      }
+    }
+  }
+  afterStar := false
+  for {
+    char, err := s.read()
+    if err == io.EOF {
+      return false, s.errorAt(ErrUnterminatedComment, span)
+    }
+    if err != nil {
+      return false, err
+    }
+    if afterStar && char == '/' {
+      return true, nil
+    }
+    afterStar = char == '*'
+  }
+}
+
+// scanString reads the rest of a string token up to the closing quote,
+// which matches the opening quote
+func (s *scanner) scanString(token *strings.Builder, span Span, quote rune) error {
+  inEscape := false
+  for {
+    charPos := s.pos
+    char, err := s.read()
+    if err == io.EOF {
+      return s.errorAt(fmt.Errorf("%w: %s", ErrUnterminatedString, token.String()), span)
+    }
+    if err != nil {
+      return err
+    }
+    token.WriteRune(char)
+    if inEscape {
+      // \' only escapes the quote of a single-quoted JSON5 string
+      if _, ok := escapes[char]; !ok && !(char == '\'' && quote == '\'') {
+        return s.errorAt(fmt.Errorf("%w: %c", ErrInvalidEscape, char), charPos)
+      }
+      inEscape = false
+    } else if char == '\\' {
+      inEscape = true
+    } else if char == quote {
+      return nil
+    }
+  }
+}
+
+// scanBare reads the rest of a token that isn't a string or punctuation -
+// 'true', 'false', 'null', numbers
+func (s *scanner) scanBare(token *strings.Builder) error {
+  for {
+    char, err := s.peek()
+    if err == io.EOF {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+    _, isSingle := singleChars[char]
+    _, isWS := wsChars[char]
+    // A comment can follow a token without whitespace between them
+    isComment := char == '/' && s.opts.allowComments()
+    isQuote := char == '"' || (char == '\'' && s.opts.JSON5)
+    if isSingle || isWS || isComment || isQuote {
+      return nil
+    }
+    s.read()
+    token.WriteRune(char)
+  }
+}
+
+// each calls fn with every remaining token as it is read, stopping at the
+// first error from fn. It returns the position it got to.
+func (s *scanner) each(fn func(token Token) error) (Span, error) {
+  for {
+    token, err := s.next()
+    if err == io.EOF {
+      return s.pos, nil
+    }
+    if err != nil {
+      return s.pos, err
+    }
+    if err := fn(token); err != nil {
+      return s.pos, err
+    }
+  }
+}
+
+// all reads every remaining token, returning them appended to tokens, and
+// the position of the end of input
+func (s *scanner) all(tokens []Token) ([]Token, Span, error) {
+  end, err := s.each(func(token Token) error {
+    tokens = append(tokens, token)
+    return nil
+  })
+  if err != nil {
+    return nil, end, err
+  }
+  return tokens, end, nil
+}
+
+// tokenize returns the tokens of input and the position of the end of
+// input
+func tokenize(input []byte, opts *Options) ([]Token, Span, error) {
+  return newScanner(bytes.NewReader(input), opts).all(nil)
+}
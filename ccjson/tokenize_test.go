@@ -0,0 +1,94 @@
+package ccjson
+
+import (
+  "bytes"
+  "errors"
+  "reflect"
+  "strings"
+  "testing"
+  "testing/iotest"
+)
+
+// stringHeavyDocument returns an array of count strings, each length
+// characters long with an escape every so often
+func stringHeavyDocument(count int, length int) []byte {
+  var b strings.Builder
+  b.WriteString("[")
+  chunk := strings.Repeat("abcdefghij", 9) + `\"\né `
+  for i := 0; i < count; i++ {
+    if i > 0 {
+      b.WriteString(",\n  ")
+    }
+    b.WriteString(`"`)
+    for written := 0; written < length; written += len(chunk) {
+      b.WriteString(chunk)
+    }
+    b.WriteString(`"`)
+  }
+  b.WriteString("]")
+  return []byte(b.String())
+}
+
+func BenchmarkTokenize(b *testing.B) {
+  // A few megabyte-long strings, where building tokens a character at a
+  // time was quadratic
+  data := stringHeavyDocument(4, 1<<20)
+  b.SetBytes(int64(len(data)))
+  b.ReportAllocs()
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    if _, err := Tokenize(data); err != nil {
+      b.Fatal(err)
+    }
+  }
+}
+
+func TestTokenizeReader(t *testing.T) {
+  docs := append([]string{"{\"a\":\r\n [1,\r2],\n\t\"b\": \"\u00e9\"}"}, readerDocuments...)
+  for _, doc := range docs {
+    want, err := Tokenize([]byte(doc))
+    if err != nil {
+      t.Fatalf("Tokenize(%q): %v", doc, err)
+    }
+    var got []Token
+    err = TokenizeReader(iotest.OneByteReader(bytes.NewReader([]byte(doc))), DefaultOptions(), func(token Token) error {
+      got = append(got, token)
+      return nil
+    })
+    if err != nil {
+      t.Errorf("TokenizeReader(%q): %v", doc, err)
+      continue
+    }
+    // Spans included
+    if !reflect.DeepEqual(got, want) {
+      t.Errorf("TokenizeReader(%q) = %+v, want %+v", doc, got, want)
+    }
+  }
+}
+
+func TestTokenizeReaderStops(t *testing.T) {
+  errStop := errors.New("stop")
+  calls := 0
+  err := TokenizeReader(bytes.NewReader([]byte(`[1, 2, 3, 4]`)), DefaultOptions(), func(token Token) error {
+    calls++
+    if token.Value == "2" {
+      return errStop
+    }
+    return nil
+  })
+  if err != errStop {
+    t.Errorf("TokenizeReader error = %v, want %v", err, errStop)
+  }
+  // [, 1, the comma and 2
+  if calls != 4 {
+    t.Errorf("TokenizeReader called fn %d times, want it to stop after 4", calls)
+  }
+  // Errors in the input are returned as from Tokenize
+  _, want := Tokenize([]byte(`[1, "ab`))
+  err = TokenizeReader(iotest.OneByteReader(bytes.NewReader([]byte(`[1, "ab`))), DefaultOptions(), func(token Token) error {
+    return nil
+  })
+  if err == nil || want == nil || err.Error() != want.Error() {
+    t.Errorf("TokenizeReader of an unterminated string error = %v, want %v", err, want)
+  }
+}
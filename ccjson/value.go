@@ -0,0 +1,228 @@
+package ccjson
+
+import (
+  "fmt"
+  "math"
+  "math/big"
+  "strconv"
+)
+
+// Kind is the JSON type of a Value
+type Kind int
+
+const (
+  KindNull Kind = iota
+  KindBool
+  KindNumber
+  KindString
+  KindArray
+  KindObject
+)
+
+func (k Kind) String() string {
+  switch k {
+    case KindNull:
+      return "null"
+    case KindBool:
+      return "bool"
+    case KindNumber:
+      return "number"
+    case KindString:
+      return "string"
+    case KindArray:
+      return "array"
+    case KindObject:
+      return "object"
+  }
+  return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Value is a node in the parsed document. Only the payload field matching
+// Kind is set, use the accessor for that Kind to read it.
+type Value struct {
+  Kind Kind
+  boolean bool
+  num float64
+  // The number exactly as written in the document
+  numText string
+  // Decoded contents of the string
+  str string
+  items []Value
+  // Members in document order
+  members []Member
+}
+
+// Member is a key/value pair of an object
+type Member struct {
+  Key string
+  Value Value
+}
+
+// Null returns a null value. The functions from Null to Object build
+// values to Marshal or Set, as Parse does from a document.
+func Null() Value {
+  return Value{Kind: KindNull}
+}
+
+// Bool returns a boolean value
+func Bool(b bool) Value {
+  return Value{Kind: KindBool, boolean: b}
+}
+
+// Number returns a number value, written as JavaScript's JSON.stringify
+// writes it, e.g. 1e+21 or 0.1. Infinity and NaN are kept, as
+// AllowNonFinite keeps them when parsing, but Marshal rejects them.
+func Number(f float64) Value {
+  text, err := canonicalNumber(f)
+  if err != nil {
+    switch {
+      case math.IsNaN(f):
+        text = "NaN"
+      case f > 0:
+        text = "Infinity"
+      default:
+        text = "-Infinity"
+    }
+  }
+  return Value{Kind: KindNumber, num: f, numText: text}
+}
+
+// String returns a string value holding s
+func String(s string) Value {
+  return Value{Kind: KindString, str: s}
+}
+
+// Array returns an array value holding a copy of items
+func Array(items ...Value) Value {
+  return Value{Kind: KindArray, items: append([]Value{}, items...)}
+}
+
+// Object returns an object value holding a copy of members, in order.
+// Keys aren't checked for duplicates, all the members are kept.
+func Object(members ...Member) Value {
+  return Value{Kind: KindObject, members: append([]Member{}, members...)}
+}
+
+func (v Value) checkKind(kind Kind) error {
+  if v.Kind != kind {
+    return fmt.Errorf("expected %s value, got %s", kind, v.Kind)
+  }
+  return nil
+}
+
+// AsString returns the contents of a string value
+func (v Value) AsString() (string, error) {
+  if err := v.checkKind(KindString); err != nil {
+    return "", err
+  }
+  return v.str, nil
+}
+
+// AsNumber returns the value of a number
+func (v Value) AsNumber() (float64, error) {
+  if err := v.checkKind(KindNumber); err != nil {
+    return 0, err
+  }
+  return v.num, nil
+}
+
+// NumberText returns a number exactly as written in the document, like
+// encoding/json's Number. Unlike AsNumber it loses no precision on values
+// such as 1e400 or 12345678901234567890.
+func (v Value) NumberText() (string, error) {
+  if err := v.checkKind(KindNumber); err != nil {
+    return "", err
+  }
+  return v.numText, nil
+}
+
+// Float64 returns a number parsed from its text by strconv.ParseFloat,
+// including the ErrRange error for numbers too large for a float64
+func (v Value) Float64() (float64, error) {
+  if err := v.checkKind(KindNumber); err != nil {
+    return 0, err
+  }
+  return strconv.ParseFloat(v.numText, 64)
+}
+
+// Int64 returns a number that is a whole number in int64 range. A fraction
+// or exponent is fine as long as the value is whole, e.g. 1.0 or 1e3.
+// Numbers out of range give an error wrapping strconv.ErrRange, as
+// Float64 does.
+func (v Value) Int64() (int64, error) {
+  if err := v.checkKind(KindNumber); err != nil {
+    return 0, err
+  }
+  if n, err := strconv.ParseInt(v.numText, 10, 64); err == nil {
+    return n, nil
+  }
+  // Rule out huge values before big.Rat spends time and memory on them
+  if f, _ := strconv.ParseFloat(v.numText, 64); math.Abs(f) > 1e19 {
+    return 0, fmt.Errorf("%s is out of int64 range: %w", v.numText, strconv.ErrRange)
+  }
+  r, ok := new(big.Rat).SetString(v.numText)
+  if !ok {
+    return 0, fmt.Errorf("cannot convert %s to int64", v.numText)
+  }
+  if !r.IsInt() {
+    return 0, fmt.Errorf("%s is not a whole number", v.numText)
+  }
+  if !r.Num().IsInt64() {
+    return 0, fmt.Errorf("%s is out of int64 range: %w", v.numText, strconv.ErrRange)
+  }
+  return r.Num().Int64(), nil
+}
+
+// AsBool returns the value of a boolean
+func (v Value) AsBool() (bool, error) {
+  if err := v.checkKind(KindBool); err != nil {
+    return false, err
+  }
+  return v.boolean, nil
+}
+
+// Items returns the elements of an array
+func (v Value) Items() ([]Value, error) {
+  if err := v.checkKind(KindArray); err != nil {
+    return nil, err
+  }
+  return v.items, nil
+}
+
+// Members returns the key/value pairs of an object in document order
+func (v Value) Members() ([]Member, error) {
+  if err := v.checkKind(KindObject); err != nil {
+    return nil, err
+  }
+  return v.members, nil
+}
+
+// Interface returns v as the types encoding/json's Unmarshal uses for an
+// interface{}: map[string]interface{}, []interface{}, string, float64, bool
+// and nil. Numbers become float64 as Unmarshal gives without UseNumber,
+// so precision past a float64 is lost, and numbers out of its range, which
+// Unmarshal rejects, become ±Inf. Use NumberText for the exact number.
+// A map can't keep member order, use Members where it matters.
+func (v Value) Interface() interface{} {
+  switch v.Kind {
+    case KindBool:
+      return v.boolean
+    case KindNumber:
+      return v.num
+    case KindString:
+      return v.str
+    case KindArray:
+      items := make([]interface{}, len(v.items))
+      for idx, item := range v.items {
+        items[idx] = item.Interface()
+      }
+      return items
+    case KindObject:
+      members := make(map[string]interface{}, len(v.members))
+      for _, member := range v.members {
+        members[member.Key] = member.Value.Interface()
+      }
+      return members
+  }
+  return nil
+}
@@ -0,0 +1,144 @@
+package ccjson
+
+import (
+  "encoding/json"
+  "errors"
+  "math"
+  "reflect"
+  "strconv"
+  "testing"
+)
+
+func mustParse(t *testing.T, doc string) Value {
+  t.Helper()
+  v, err := Parse([]byte(doc))
+  if err != nil {
+    t.Fatalf("Parse(%q): %v", doc, err)
+  }
+  return v
+}
+
+func TestInt64(t *testing.T) {
+  tests := []struct {
+    doc string
+    want int64
+    // The error wraps strconv.ErrRange
+    wantRange bool
+    wantErr bool
+  }{
+    {"0", 0, false, false},
+    {"-0", 0, false, false},
+    {"42", 42, false, false},
+    {"1.0", 1, false, false},
+    {"1e3", 1000, false, false},
+    {"1.5e1", 15, false, false},
+    {"-2E+2", -200, false, false},
+    {"1.5", 0, false, true},
+    {"1e-3", 0, false, true},
+    {"9223372036854775807", math.MaxInt64, false, false},
+    {"-9223372036854775808", math.MinInt64, false, false},
+    {"9223372036854775808", 0, true, true},
+    {"-9223372036854775809", 0, true, true},
+    {"9.223372036854775808e18", 0, true, true},
+    {"1e400", 0, true, true},
+    {"-1e400", 0, true, true},
+  }
+  for _, tt := range tests {
+    got, err := mustParse(t, tt.doc).Int64()
+    if (err != nil) != tt.wantErr {
+      t.Errorf("Int64(%s) error = %v, wantErr %v", tt.doc, err, tt.wantErr)
+      continue
+    }
+    if errors.Is(err, strconv.ErrRange) != tt.wantRange {
+      t.Errorf("Int64(%s) error = %v, want ErrRange %v", tt.doc, err, tt.wantRange)
+    }
+    if got != tt.want {
+      t.Errorf("Int64(%s) = %d, want %d", tt.doc, got, tt.want)
+    }
+  }
+}
+
+func TestFloat64(t *testing.T) {
+  tests := []struct {
+    doc string
+    want float64
+    wantRange bool
+  }{
+    {"0", 0, false},
+    {"1.0", 1, false},
+    {"1e3", 1000, false},
+    {"1.5", 1.5, false},
+    {"-2.5E-1", -0.25, false},
+    {"9223372036854775807", 9223372036854775807, false},
+    {"-9223372036854775809", -9223372036854775809, false},
+    {"1e400", math.Inf(1), true},
+    {"-1e400", math.Inf(-1), true},
+  }
+  for _, tt := range tests {
+    got, err := mustParse(t, tt.doc).Float64()
+    if errors.Is(err, strconv.ErrRange) != tt.wantRange || (err != nil && !tt.wantRange) {
+      t.Errorf("Float64(%s) error = %v, want ErrRange %v", tt.doc, err, tt.wantRange)
+    }
+    if got != tt.want {
+      t.Errorf("Float64(%s) = %v, want %v", tt.doc, got, tt.want)
+    }
+  }
+}
+
+func TestNumberAccessorsWrongKind(t *testing.T) {
+  v := mustParse(t, `"1"`)
+  if _, err := v.Int64(); err == nil {
+    t.Error("Int64 of a string succeeded")
+  }
+  if _, err := v.Float64(); err == nil {
+    t.Error("Float64 of a string succeeded")
+  }
+}
+
+func TestInterface(t *testing.T) {
+  docs := []string{
+    `null`,
+    `true`,
+    `false`,
+    `0`,
+    `-0`,
+    `1.5e3`,
+    `12345678901234567890`,
+    `0.1`,
+    `"aé\n😀 \u00e9\ud83d\ude00"`,
+    `{"k\u00e9y": 1}`,
+    `[]`,
+    `{}`,
+    `[1, "a", null, [true, {}]]`,
+    `{"a": 1, "b": [2, 3], "c": {"d": null, "e": ""}}`,
+    `{"a": 1, "list": [{"x": 1.0}, {"y": -2e-3}]}`,
+  }
+  for _, doc := range docs {
+    var want interface{}
+    if err := json.Unmarshal([]byte(doc), &want); err != nil {
+      t.Fatalf("json.Unmarshal(%s): %v", doc, err)
+    }
+    if got := mustParse(t, doc).Interface(); !reflect.DeepEqual(got, want) {
+      t.Errorf("Interface(%s) = %#v, want %#v", doc, got, want)
+    }
+  }
+}
+
+func TestInterfaceEmptyContainers(t *testing.T) {
+  // Empty, not nil, as json.Unmarshal gives
+  if got, ok := mustParse(t, `[]`).Interface().([]interface{}); !ok || got == nil {
+    t.Errorf("Interface([]) = %#v, want an empty []interface{}", got)
+  }
+  if got, ok := mustParse(t, `{}`).Interface().(map[string]interface{}); !ok || got == nil {
+    t.Errorf("Interface({}) = %#v, want an empty map[string]interface{}", got)
+  }
+}
+
+func TestInterfaceOutOfRange(t *testing.T) {
+  // json.Unmarshal rejects these, Interface gives ±Inf
+  got := mustParse(t, `[1e400, -1e400]`).Interface()
+  want := []interface{}{math.Inf(1), math.Inf(-1)}
+  if !reflect.DeepEqual(got, want) {
+    t.Errorf("Interface([1e400, -1e400]) = %#v, want %#v", got, want)
+  }
+}
@@ -1,3 +1,3 @@
-module main
+module github.com/tn259/cc-json-parser
 
 go 1.19
@@ -1,744 +1,418 @@
 package main
 
 import (
-	"fmt"
-	"os"
-  "runtime/debug"
+  "bytes"
+  "compress/gzip"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strings"
+
+  "github.com/tn259/cc-json-parser/ccjson"
 )
 
-// https://www.json.org/json-en.html
+// Exit codes. With several files, an I/O error on any of them takes
+// precedence over invalid JSON in another.
+const (
+  // Every file is valid JSON
+  exitValid = 0
+  // At least one file is not valid JSON
+  exitInvalid = 1
+  // A file could not be opened or read, or the command line was wrong
+  exitIOError = 2
+  // With -diff, the two documents differ. Invalid JSON in either of them
+  // gives exitIOError, as diff(1) exits 2 for trouble.
+  exitDifferent = 1
+)
 
-// This implementation sets no limits on nesting depths
-// https://www.rfc-editor.org/rfc/rfc8259.html#section-9
+var requireFinalNewline = flag.Bool("require-final-newline", false, "fail if the file does not end with a newline")
+var duplicateKeys = flag.String("duplicate-keys", "error", "how to handle repeated object keys: error, first or last")
+var jsonc = flag.Bool("jsonc", false, "allow // and /* */ comments (JSONC)")
+var trailingCommas = flag.Bool("trailing-commas", false, "allow a comma after the last element of an array or object")
+var requireContainer = flag.Bool("require-container", false, "fail if the top-level value isn't an object or array")
+var nonFinite = flag.Bool("non-finite", false, "allow Infinity, -Infinity and NaN as numbers")
+var json5 = flag.Bool("json5", false, "allow single-quoted strings, identifier keys, comments and trailing commas (JSON5 style)")
+var maxBytes = flag.Int("max-bytes", 0, "fail if the input is longer than this many bytes, 0 for no limit")
+var maxTokens = flag.Int("max-tokens", 0, "fail if the input has more than this many tokens, 0 for no limit")
+var gz = flag.Bool("gz", false, "decompress the input with gzip, as is always done for files ending .gz")
+var ndjson = flag.Bool("ndjson", false, "treat each line as a separate document (newline-delimited JSON)")
+var stream = flag.Bool("stream", false, "treat the input as a sequence of documents one after another, e.g. {\"a\":1}{\"b\":2}")
+var pretty = flag.Bool("pretty", false, "print the document reformatted instead of the token dump")
+var minify = flag.Bool("min", false, "print the document with insignificant whitespace removed instead of the token dump")
+var canonical = flag.Bool("canonical", false, "print the document as canonical JSON (RFC 8785) instead of the token dump")
+var query = flag.String("query", "", "print the values matching a JSONPath-style query, e.g. '$.users[2].name', instead of the token dump")
+var diff = flag.Bool("diff", false, "compare two files, printing the values added (+), removed (-) and changed (~)")
+var set = flag.String("set", "", "set the value at a JSON Pointer, given as pointer=json, e.g. '/servers/0/port=8443', and print the result")
+var create = flag.Bool("create", false, "with -set, create objects missing along the pointer")
+var del = flag.String("delete", "", "remove the member or element at a JSON Pointer, e.g. '/servers/0', and print the result")
+var merge = flag.Bool("merge", false, "merge the objects in two or more files, later ones overriding earlier ones, and print the result")
+var stats = flag.Bool("stats", false, "print counts of each kind of value, the maximum nesting depth and the number of tokens")
+var ascii = flag.Bool("ascii", false, "with -pretty, -min, -query, -diff, -set, -delete or -merge, write non-ASCII characters as \\uXXXX escapes")
+var sortKeys = flag.Bool("sort-keys", false, "with -pretty, -min, -query, -diff, -set, -delete or -merge, write object members sorted by key")
+var indent = flag.String("indent", "  ", "indentation for each level of nesting with -pretty, -query, -set, -delete or -merge")
+var quiet bool
 
-var singleChars = map[rune]bool{
-  '{': true,
-  '}': true,
-  '[': true,
-  ']': true,
-  ',': true,
-  ':': true,
+var duplicateKeyPolicies = map[string]ccjson.DuplicateKeyPolicy{
+  "error": ccjson.DuplicateKeyError,
+  "first": ccjson.DuplicateKeyFirstWins,
+  "last": ccjson.DuplicateKeyLastWins,
 }
-var wsChars = map[rune]bool{
-  ' ': true,
-  '\t': true,
-  '\n': true,
-  '\r': true,
-}
-var keywords = map[string]bool{
-  "true": true,
-  "false": true,
-  "null": true,
+
+func init() {
+  flag.BoolVar(&quiet, "q", false, "only print errors, no token dump or per-file results")
+  flag.BoolVar(&quiet, "quiet", false, "same as -q")
 }
-var escapes = map[rune]bool{
-  '\\': true,
-  '"': true,
-  '/': true,
-  'b': true,
-  'f': true,
-  'n': true,
-  'r': true,
-  't': true,
-  'u': true,
+
+func usage() {
+  fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [file.json ...]\n\nReads from stdin if no file is given.\n\n", os.Args[0])
+  flag.PrintDefaults()
 }
 
-func tokenize(input string) ([]string, error) {
-  var tokens []string
-  var currentToken string
-  inString := false
-  inEscape := false
-  for _, char := range input {
-    startString := !inString && char == '"'
-    endString := inString && char == '"' && !inEscape
-    if startString || endString {
-      if !inString {
-        inString = true
-        currentToken = "\""
-      } else {
-        inString = false
-        currentToken += "\""
-        tokens = append(tokens, currentToken)
-        currentToken = ""
-      }
-      continue
-    }
-    if inString {
-      if inEscape {
-        if _, ok := escapes[char]; !ok {
-          return nil, fmt.Errorf("invalid escape char: %c", char)
-        }
-        inEscape = false
-      } else if char == '\\' && !inEscape {
-        inEscape = true
-      }
-      currentToken += string(char)
-      continue
-    }
-    if _, ok := singleChars[char]; ok {
-      if len(currentToken) > 0 {
-        tokens = append(tokens, currentToken)
-        currentToken = ""
-      }
-      tokens = append(tokens, string(char))
-      continue
-    }
-    if _, ok := wsChars[char]; ok {
-      continue
-    }
-    // Other values - 'true', 'false', 'null', numbers
-    currentToken += string(char)
-  }
-  return tokens, nil
+// formatOptions returns the options for writing JSON set by the flags
+func formatOptions() ccjson.FormatOptions {
+  return ccjson.FormatOptions{EscapeNonASCII: *ascii, SortKeys: *sortKeys}
 }
 
-// json
-//   element
-func parse(tokens []string) error {
-  if len(tokens) == 0 {
-    return fmt.Errorf("empty input")
-  }
-  var err error
-  idx := 0
-  if tokens[0] == "{" {
-    idx, err = parseObject(idx, tokens)
+// readFile returns the contents of jsonFilename, or stdin if jsonFilename
+// is empty, decompressed as it is read if it is gzipped
+func readFile(jsonFilename string) ([]byte, error) {
+  jsonFile := os.Stdin
+  if jsonFilename != "" {
+    var err error
+    jsonFile, err = os.Open(jsonFilename)
     if err != nil {
-      return fmt.Errorf("parseObject(): %w", err)
+      return nil, fmt.Errorf("error opening json file: %w", err)
     }
-  } else if tokens[0] == "[" {
-    idx, err = parseArray(idx, tokens)
+    defer jsonFile.Close()
+    jsonFileInfo, err := jsonFile.Stat()
     if err != nil {
-      return fmt.Errorf("parseArray(): %w", err)
+      return nil, fmt.Errorf("error reading json file: %w", err)
+    }
+    if jsonFileInfo.IsDir() {
+      return nil, fmt.Errorf("expected a file, got a directory: %s", jsonFilename)
     }
-  } else {
-    return fmt.Errorf("JSON payload should be object or array")
-  }
-  if idx != len(tokens) {
-    return fmt.Errorf("unexpected token: %s", tokens[idx])
-  }
-  return nil
-}
-
-// Accessing token within tokens
-func tokenInBounds(index int, tokens []string) bool {
-  return index >= 0 && index < len(tokens)
-}
-func getToken(index int, tokens []string) (string, error) {
-  if !tokenInBounds(index, tokens) {
-    debug.PrintStack()
-    return "", fmt.Errorf("token index out of range")
-  }
-  return tokens[index], nil
-}
-// Accessing runes within a token
-func runeInBounds(index int, token string) bool {
-  return index >= 0 && index < len(token)
-}
-func getRune(index int, token string) (rune, error) {
-  if !runeInBounds(index, token) {
-    debug.PrintStack()
-    return 0, fmt.Errorf("rune index %d out of range in %s", index, token)
-  }
-  return []rune(token)[index], nil
-}
-
-// element
-//   ws value ws
-func parseElement(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if isWS(token) {
-    currentTokenIdx++
-  }
-  currentTokenIdx, err = parseValue(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseValue(): %w", err)
-  }
-  if !tokenInBounds(currentTokenIdx, tokens) {
-    return currentTokenIdx, nil
-  }
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if isWS(token) {
-    currentTokenIdx++
   }
-  return currentTokenIdx, nil
-}
-
-// elements
-//   element
-//   element ',' elements
-func parseElements(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
+  var r io.Reader = jsonFile
+  if *gz || strings.HasSuffix(jsonFilename, ".gz") {
+    zr, err := gzip.NewReader(jsonFile)
+    if err != nil {
+      return nil, fmt.Errorf("error decompressing json file: %w", err)
+    }
+    defer zr.Close()
+    r = zr
   }
-  currentTokenIdx, err = parseElement(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseElement(): %w", err)
+  // One byte over the limit is enough for the parser to report it, and
+  // stops a small gzipped file expanding into a huge one in memory
+  if *maxBytes > 0 {
+    r = io.LimitReader(r, int64(*maxBytes)+1)
   }
-  token, err = getToken(currentTokenIdx, tokens)
+  jsonData, err := io.ReadAll(r)
   if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if token == "," {
-    return parseElements(currentTokenIdx+1, tokens)
+    return nil, fmt.Errorf("error reading json file: %w", err)
   }
-  return currentTokenIdx, nil
+  return jsonData, nil
 }
 
-// value
-//   object
-//   array
-//   string
-//   number
-//   "true"
-//   "false"
-//   "null"
-func parseValue(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
+// checkFile validates the json in jsonFilename, or stdin if jsonFilename
+// is empty, returning the exit code for the result
+func checkFile(jsonFilename string, opts ccjson.Options) (int, error) {
+  jsonData, err := readFile(jsonFilename)
   if err != nil {
-    return currentTokenIdx, err
-  }
-  if token == "{" {
-    return parseObject(currentTokenIdx, tokens)
+    return exitIOError, err
   }
-  if token == "[" {
-    return parseArray(currentTokenIdx, tokens)
+  if *requireFinalNewline && !bytes.HasSuffix(jsonData, []byte("\n")) {
+    return exitInvalid, fmt.Errorf("error: json file does not end with a newline")
   }
-  if token[0] == '"' {
-    return parseString(currentTokenIdx, tokens)
+  if *ndjson {
+    return checkLines(jsonFilename, jsonData, opts)
   }
-  if _, ok := keywords[token]; ok {
-    return currentTokenIdx+1, nil
+  if *stream {
+    return checkStream(jsonData, opts)
   }
-  if _, err := parseNumber(currentTokenIdx, tokens); err != nil {
-    return currentTokenIdx, fmt.Errorf("parseNumber(): %w", err)
-  }
-  return currentTokenIdx+1, nil
-}
 
-// number
-//   integer fraction exponent
-func parseNumber(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  idx := 0
-  idx, err = parseInteger(idx, token)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseInteger(): %w", err)
-  }
-  if idx == len(token) {
-    return currentTokenIdx+1, nil
-  }
-  inBounds := runeInBounds(idx, token)
-  if !inBounds {
-    return currentTokenIdx, fmt.Errorf("rune idx out of bounds")
-  }
-  c, err := getRune(idx, token)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c == '.' {
-    idx, err = parseFraction(idx, token)
+  // The token dump is only worth tokenizing for when it's printed, parsing
+  // tokenizes again
+  if !quiet {
+    tokens, err := ccjson.TokenizeWithOptions(jsonData, opts)
     if err != nil {
-      return idx, fmt.Errorf("parseFraction(): %w", err)
+      return exitInvalid, fmt.Errorf("error tokenizing json file: %s", describeError(jsonData, err))
+    }
+    values := make([]string, len(tokens))
+    for idx, token := range tokens {
+      values[idx] = token.Value
+    }
+    fmt.Println(values)
+    for idx, token := range tokens {
+      fmt.Printf("%d %s %d:%d\n", idx, token.Value, token.Line, token.Col)
     }
   }
-  if idx == len(token) {
-    return currentTokenIdx+1, nil
-  }
-  inBounds = runeInBounds(idx, token)
-  if !inBounds {
-    return currentTokenIdx, fmt.Errorf("rune idx out of bounds")
+  var value ccjson.Value
+  if *stats {
+    var valueStats ccjson.Stats
+    value, valueStats, err = ccjson.ParseWithStats(jsonData, opts)
+    if err == nil {
+      fmt.Printf("stats: %s\n", valueStats)
+    }
+  } else {
+    value, err = ccjson.ParseWithOptions(jsonData, opts)
   }
-  c, err = getRune(idx, token)
   if err != nil {
-    return currentTokenIdx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c == 'e' || c == 'E' {
-    idx, err = parseExponent(idx, token)
-    if err != nil {
-      return idx, fmt.Errorf("parseExponent(): %w", err)
-    }
+    return exitInvalid, fmt.Errorf("error parsing json: %s", describeError(jsonData, err))
   }
-  if idx != len(token) {
-    return idx, fmt.Errorf("Unexpected token: %s", token[idx:])
+  if *pretty {
+    fmt.Println(ccjson.FormatWithOptions(value, *indent, formatOptions()))
   }
-  return currentTokenIdx+1, nil
-}
-
-// integer
-//   digit
-//   onenine digits
-//   '-' digit
-//   '-' onenine digits
-func parseInteger(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
+  if *minify {
+    fmt.Println(ccjson.CompactWithOptions(value, formatOptions()))
   }
-  if c == '-' {
-    idx++
-    c, err = getRune(idx, token)
+  if *canonical {
+    canonicalJSON, err := ccjson.Canonicalize(value)
     if err != nil {
-      return idx, fmt.Errorf("getRune(): %w", err)
+      return exitInvalid, fmt.Errorf("error canonicalizing json: %w", err)
     }
+    fmt.Println(string(canonicalJSON))
   }
-  // onenine first case
-  if c >= '1' && c <= '9' {
-    idx, err := parseOnenine(idx, token)
+  if *query != "" {
+    matches, err := value.Query(*query)
     if err != nil {
-      return idx, fmt.Errorf("parseOnenine(): %w", err)
+      return exitIOError, err
     }
-    if idx == len(token) {
-      return idx, nil
+    for _, match := range matches {
+      fmt.Println(ccjson.FormatWithOptions(match, *indent, formatOptions()))
+    }
+  }
+  if *set != "" {
+    pointer, newJSON, ok := strings.Cut(*set, "=")
+    if !ok {
+      return exitIOError, fmt.Errorf("-set %q should be pointer=json", *set)
     }
-    idx, err = parseDigits(idx, token)
+    newValue, err := ccjson.ParseWithOptions([]byte(newJSON), opts)
     if err != nil {
-      return idx, fmt.Errorf("parseDigits(): %w", err)
+      return exitIOError, fmt.Errorf("error parsing -set value: %w", err)
     }
-    return idx, nil
-  }
-  // digit first case
-  idx, err = parseDigit(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseDigit(): %w", err)
-  }
-  return idx, nil
-}
-
-// digit
-//   '0'
-//    onenine
-func parseDigit(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c == '0' {
-    return idx+1, nil
-  }
-  idx, err = parseOnenine(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseOnenine(): %w", err)
-  }
-  return idx, nil
-}
-
-// digits
-//   digit
-//   digit digits
-func parseDigits(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  // Stop at fraction or exponent
-  if c == '.' || c == 'e' || c == 'E' {
-    return idx, nil
-  }
-  idx, err = parseDigit(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseDigit(): %w", err)
-  }
-  if idx == len(token) {
-    return idx, nil
-  }
-  idx, err = parseDigits(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseDigits(): %w", err)
-  }
-  return idx, nil
-}
-
-// onenine
-//   '1' . '9'
-func parseOnenine(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c < '1' || c > '9' {
-    return idx, fmt.Errorf("Expected onenine, got %c in %s", c, token)
-  }
-  return idx+1, nil
-}
-
-// fraction
-//   "." digits
-func parseFraction(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c != '.' {
-    return idx, fmt.Errorf("Expected '.', got %c in %s", c, token)
+    if err := value.Set(pointer, newValue, *create); err != nil {
+      return exitInvalid, fmt.Errorf("error setting value: %w", err)
+    }
+    fmt.Println(ccjson.FormatWithOptions(value, *indent, formatOptions()))
   }
-  idx++
-  idx, err = parseDigits(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseDigits(): %w", err)
+  if *del != "" {
+    if err := value.Delete(*del); err != nil {
+      return exitInvalid, fmt.Errorf("error deleting value: %w", err)
+    }
+    fmt.Println(ccjson.FormatWithOptions(value, *indent, formatOptions()))
   }
-  return idx, nil
+  return exitValid, nil
 }
 
-// exponent
-//   'E' sign digits
-//   'e' sign digits
-func parseExponent(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c != 'E' && c != 'e' {
-    return idx, fmt.Errorf("Expected 'E' or 'e', got %c in %s", c, token)
-  }
-  idx++
-  c, err = getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c == '+' || c == '-' {
-    idx++
-  }
-  idx, err = parseDigits(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseDigits(): %w", err)
-  }
-  return idx, nil
+// describeError returns err with the line of jsonData it is on and a
+// caret under where it is, if it has a position
+func describeError(jsonData []byte, err error) string {
+  var parseErr *ccjson.ParseError
+  if errors.As(err, &parseErr) {
+    return ccjson.FormatError(jsonData, *parseErr)
+  }
+  return err.Error()
 }
 
-// sign
-//   '+'
-//   '-'
-func parseSign(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
+// checkLines validates each line of jsonData as a separate document,
+// printing an error for every invalid line
+func checkLines(jsonFilename string, jsonData []byte, opts ccjson.Options) (int, error) {
+  prefix := ""
+  if jsonFilename != "" {
+    prefix = jsonFilename + ": "
+  }
+  lines := ccjson.ParseLines(jsonData, opts)
+  invalid := 0
+  for _, line := range lines {
+    if line.Err != nil {
+      fmt.Printf("%serror parsing json: %s\n", prefix, describeError(jsonData, line.Err))
+      invalid++
+    }
   }
-  if c != '+' && c != '-' {
-    return idx, fmt.Errorf("Expected '+', '-', got %c in %s", c, token)
+  if invalid > 0 {
+    return exitInvalid, fmt.Errorf("%d of %d lines invalid", invalid, len(lines))
   }
-  return idx+1, nil
+  return exitValid, nil
 }
 
-// members
-//   member
-//   member ',' members
-func parseMembers(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  currentTokenIdx, err = parseMember(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseMember(): %w", err)
-  }
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
+// checkStream validates jsonData as a sequence of documents, stopping at
+// the first invalid one
+func checkStream(jsonData []byte, opts ccjson.Options) (int, error) {
+  dec := ccjson.NewDecoder(bytes.NewReader(jsonData), opts)
+  count := 0
+  for dec.More() {
+    if _, err := dec.Decode(); err != nil {
+      return exitInvalid, fmt.Errorf("error parsing json value %d: %s", count+1, describeError(jsonData, err))
+    }
+    count++
   }
-  if token == string(',') {
-    return parseMembers(currentTokenIdx+1, tokens)
+  if !quiet {
+    fmt.Printf("%d values\n", count)
   }
-  return currentTokenIdx, nil
+  return exitValid, nil
 }
 
-// member
-//   ws string ws ':' element
-func parseMember(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if isWS(token) {
-    currentTokenIdx++
-  }
-  currentTokenIdx, err = parseString(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseString(): %w", err)
-  }
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if isWS(token) {
-    currentTokenIdx++
-    token, err = getToken(currentTokenIdx, tokens)
+// diffFiles prints the differences between the documents in two files
+func diffFiles(oldFilename string, newFilename string, opts ccjson.Options) (int, error) {
+  var values [2]ccjson.Value
+  for idx, jsonFilename := range []string{oldFilename, newFilename} {
+    jsonData, err := readFile(jsonFilename)
     if err != nil {
-      return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
+      return exitIOError, fmt.Errorf("%s: %w", jsonFilename, err)
+    }
+    values[idx], err = ccjson.ParseWithOptions(jsonData, opts)
+    if err != nil {
+      return exitIOError, fmt.Errorf("%s: error parsing json: %s", jsonFilename, describeError(jsonData, err))
     }
   }
-  if token != string(':') {
-    return currentTokenIdx, fmt.Errorf("Expected ':', got %s", token)
-  }
-  currentTokenIdx++
-  return parseElement(currentTokenIdx, tokens)  
-}  
-
-// string
-//   '"' characters '"'
-func parseString(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if token[0] != '"' {
-    debug.PrintStack()
-    return currentTokenIdx, fmt.Errorf("expected string starting with \", got %s", token)
+  changes := ccjson.Diff(values[0], values[1])
+  compact := func(v ccjson.Value) string {
+    return ccjson.CompactWithOptions(v, formatOptions())
   }
-  if token[len(token)-1] != '"' {
-    return currentTokenIdx, fmt.Errorf("expected string ending with \", got %s", token)
+  for _, change := range changes {
+    path := change.Path
+    if path == "" {
+      path = "(root)"
+    }
+    switch change.Kind {
+      case ccjson.ChangeAdded:
+        fmt.Printf("+ %s: %s\n", path, compact(change.New))
+      case ccjson.ChangeRemoved:
+        fmt.Printf("- %s: %s\n", path, compact(change.Old))
+      case ccjson.ChangeModified:
+        fmt.Printf("~ %s: %s -> %s\n", path, compact(change.Old), compact(change.New))
+    }
   }
-  idx := 1
-  idx, err = parseCharacters(idx, token)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseCharacters(): %w", err)
+  if len(changes) > 0 {
+    return exitDifferent, nil
   }
-  return currentTokenIdx+1, nil
+  return exitValid, nil
 }
 
-// characters
-//   ""
-//   character characters
-func parseCharacters(idx int, token string) (int, error) {
-  if idx == len(token)-1 {
-    if token[idx] != '"' {
-      return idx, fmt.Errorf("expected string ending with \", got %s", token)
+// mergeFiles prints the objects in jsonFilenames merged into the first in
+// turn
+func mergeFiles(jsonFilenames []string, opts ccjson.Options) (int, error) {
+  var merged ccjson.Value
+  for idx, jsonFilename := range jsonFilenames {
+    jsonData, err := readFile(jsonFilename)
+    if err != nil {
+      return exitIOError, fmt.Errorf("%s: %w", jsonFilename, err)
+    }
+    value, err := ccjson.ParseWithOptions(jsonData, opts)
+    if err != nil {
+      return exitInvalid, fmt.Errorf("%s: error parsing json: %s", jsonFilename, describeError(jsonData, err))
+    }
+    if idx == 0 {
+      merged = value
+      continue
+    }
+    merged, err = ccjson.Merge(merged, value)
+    if err != nil {
+      return exitInvalid, fmt.Errorf("%s: %w", jsonFilename, err)
     }
-    return idx+1, nil
-  }
-  idx, err := parseCharacter(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseCharacter(): %w", err)
-  }
-  idx, err = parseCharacters(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseCharacters(): %w", err)
   }
-  return idx, nil
+  fmt.Println(ccjson.FormatWithOptions(merged, *indent, formatOptions()))
+  return exitValid, nil
 }
 
-// character
-//   '0020' . '10FFFF' - '"' - '\'
-//   '\' escape
-func parseCharacter(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
+func main() {
+  flag.Usage = usage
+  flag.Parse()
+  opts := ccjson.DefaultOptions()
+  policy, ok := duplicateKeyPolicies[*duplicateKeys]
+  if !ok {
+    fmt.Printf("invalid -duplicate-keys %q, expected error, first or last\n", *duplicateKeys)
+    os.Exit(exitIOError)
+  }
+  opts.DuplicateKeys = policy
+  opts.RequireContainer = *requireContainer
+  opts.AllowComments = *jsonc
+  opts.AllowTrailingCommas = *trailingCommas
+  opts.AllowNonFinite = *nonFinite
+  opts.JSON5 = *json5
+  opts.MaxInputBytes = *maxBytes
+  opts.MaxTokens = *maxTokens
+  outputModes := 0
+  for _, mode := range []bool{*pretty, *minify, *canonical, *query != "", *set != "", *del != "", *diff, *merge} {
+    if mode {
+      outputModes++
+    }
   }
-  if c == '\\' {
-    return parseEscape(idx+1, token)
+  if outputModes > 1 {
+    fmt.Println("only one of -pretty, -min, -canonical, -query, -set, -delete, -diff and -merge can be used")
+    os.Exit(exitIOError)
   }
-  if c < 0x0020 || c > 0x10FFFF {
-    return idx, fmt.Errorf("expected character, got %q, in %s", c, token)
+  if *ndjson && *stream {
+    fmt.Println("only one of -ndjson and -stream can be used")
+    os.Exit(exitIOError)
   }
-  return idx+1, nil
-}
-
-// escape
-//   '"'
-//   '\'
-//   '/'
-//   'b'
-//   'f'
-//   'n'
-//   'r'
-//   't'
-//   'u' hex hex hex hex
-func parseEscape(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
+  if outputModes > 0 && (*ndjson || *stream) {
+    fmt.Println("-ndjson and -stream can't be combined with -pretty, -min, -canonical, -query, -set, -delete, -diff or -merge")
+    os.Exit(exitIOError)
   }
-  switch c {
-    case 'u':
-      idx, err = parseHex(idx+1, token)
-      if err != nil {
-        return idx, fmt.Errorf("parseHex(): %w", err)
-      }
-      idx, err = parseHex(idx, token)
-      if err != nil {
-        return idx, fmt.Errorf("parseHex(): %w", err)
-      }
-      idx, err = parseHex(idx, token)
-      if err != nil {
-        return idx, fmt.Errorf("parseHex(): %w", err)
-      }
-      idx, err = parseHex(idx, token)
-      if err != nil {
-        return idx, fmt.Errorf("parseHex(): %w", err)
-      }
-      return idx, nil
-    case '"':
-      return idx+1, nil
-    case '\\':
-      return idx+1, nil
-    case '/':
-      return idx+1, nil
-    case 'b':
-      return idx+1, nil
-    case 'f':
-      return idx+1, nil
-    case 'n':
-      return idx+1, nil
-    case 'r':
-      return idx+1, nil
-    case 't':
-      return idx+1, nil
-  }
-  return idx, fmt.Errorf("expected escape character, got %c in %s", c, token)
-}
-
-// hex
-//   digit
-//   'A' . 'F'
-//   'a' . 'f'
-func parseHex(idx int, token string) (int, error) {
-  c, err := getRune(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("getRune(): %w", err)
-  }
-  if c >= 'A' && c <= 'F' {
-    return idx+1, nil
-  }
-  if c >= 'a' && c <= 'f' {
-    return idx+1, nil
+  // Only the formatted document goes to stdout, plus any errors
+  if outputModes == 1 {
+    quiet = true
   }
-  idx, err = parseDigit(idx, token)
-  if err != nil {
-    return idx, fmt.Errorf("parseDigit(): %w", err)
-  }
-  return idx, nil
-}
 
-// object
-//  '{' ws '}'
-//  '{' members '}'
-func parseObject(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if token != "{" {
-    return currentTokenIdx, fmt.Errorf("expected '{', got %s", token)
-  }
-  currentTokenIdx++
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  // empty object case
-  if isWS(token) {
-    currentTokenIdx++
-    token, err = getToken(currentTokenIdx, tokens)
+  if *diff {
+    if flag.NArg() != 2 {
+      fmt.Println("-diff needs two files, old and new")
+      os.Exit(exitIOError)
+    }
+    code, err := diffFiles(flag.Arg(0), flag.Arg(1), opts)
     if err != nil {
-      return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
+      fmt.Println(err)
     }
+    os.Exit(code)
   }
-  if token == "}" {
-    return currentTokenIdx+1, nil
-  }
-  currentTokenIdx, err = parseMembers(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseMembers(): %w", err)
-  }
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if token == "}" {
-    return currentTokenIdx+1, nil
-  }
-  return currentTokenIdx, fmt.Errorf("expected '}' but got '%s'", token)
-}
 
-// array
-//   '[' ws ']'
-//   '[' elements ']'
-func parseArray(currentTokenIdx int, tokens []string) (int, error) {
-  token, err := getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if token != "[" {
-    return currentTokenIdx, fmt.Errorf("expected '[' but got '%s'", token)
-  }
-  currentTokenIdx++
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  // empty object case
-  if isWS(token) {
-    currentTokenIdx++
-    token, err = getToken(currentTokenIdx, tokens)
+  if *merge {
+    if flag.NArg() < 2 {
+      fmt.Println("-merge needs at least two files")
+      os.Exit(exitIOError)
+    }
+    code, err := mergeFiles(flag.Args(), opts)
     if err != nil {
-      return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
+      fmt.Println(err)
     }
+    os.Exit(code)
   }
-  if token == "]" {
-    return currentTokenIdx+1, nil
-  }
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  currentTokenIdx, err = parseElements(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("parseElements(): %w", err)
-  }
-  token, err = getToken(currentTokenIdx, tokens)
-  if err != nil {
-    return currentTokenIdx, fmt.Errorf("getToken(): %w", err)
-  }
-  if token != "]" {
-    return currentTokenIdx, fmt.Errorf("expected ']' but got '%s'", token)
-  }
-  return currentTokenIdx+1, nil
-}
 
-func isWS(token string) bool {
-  for _, char := range token {
-    if _, ok := wsChars[char]; !ok {
-      return false
+  if flag.NArg() == 0 {
+    // Nothing piped in either, so the user most likely forgot the file
+    stdinInfo, err := os.Stdin.Stat()
+    if err != nil || stdinInfo.Mode()&os.ModeCharDevice != 0 {
+      flag.Usage()
+      os.Exit(exitIOError)
     }
-  }
-  return true
-}
-
-func main() {
-	jsonFilename := os.Args[1]
-  jsonFile, err := os.Open(jsonFilename)
-  if err != nil {
-    fmt.Println("error opening json file: ", err)
-    os.Exit(1)
-  }
-  defer jsonFile.Close()
-
-  jsonData, err := os.ReadFile(jsonFilename)
-  if err != nil {
-    fmt.Println("error reading json file: ", err)
-    os.Exit(1)
+    code, err := checkFile("", opts)
+    if err != nil {
+      fmt.Println(err)
+    }
+    os.Exit(code)
   }
 
-  tokens, err := tokenize(string(jsonData))
-  if err != nil {
-    fmt.Println("error tokenizing json file: ", err)
-    os.Exit(1)
-  }
-  fmt.Println(tokens)
-  for idx, token := range tokens {
-    fmt.Printf("%d %s\n", idx, token)
+  valid := 0
+  exitCode := exitValid
+  for _, jsonFilename := range flag.Args() {
+    code, err := checkFile(jsonFilename, opts)
+    if err != nil {
+      fmt.Printf("%s: %s\n", jsonFilename, err)
+    } else {
+      if !quiet {
+        fmt.Printf("%s: valid\n", jsonFilename)
+      }
+      valid++
+    }
+    if code > exitCode {
+      exitCode = code
+    }
   }
-  if err = parse(tokens); err != nil {
-    fmt.Println("error parsing json: ", err)
-    os.Exit(1)
+  if flag.NArg() > 1 && !quiet {
+    fmt.Printf("%d of %d files valid\n", valid, flag.NArg())
   }
+  os.Exit(exitCode)
 }